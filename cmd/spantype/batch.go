@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/apstndb/spantype"
+)
+
+func runBatch(args []string) error {
+	fs := newFlagSet("batch", "[FILE]", "batch formats types in NDJSON read from FILE or stdin line by line.\n"+
+		"Each line is written back with the formatted type added as a field, or only the formatted type is printed with -print.")
+	path := fs.String("path", "", "dot-separated JSON path to the type in each line, e.g. response.metadata or items.0.type; empty means the whole line")
+	field := fs.String("field", "spantype", "name of the field added to each line")
+	printOnly := fs.Bool("print", false, "print only the formatted type of each line instead of the line")
+	mode := fs.String("mode", "verbose", "format mode (simplest|simple|normal|verbose|more), or a spantype.ParseFormatOption specification")
+	inputKind := fs.String("input", inputAuto, "input kind ("+strings.Join(inputKinds, "|")+")")
+	parallel := fs.Int("parallel", runtime.GOMAXPROCS(0), "number of lines processed concurrently")
+	optionFlags := addFormatOptionFlags(fs)
+	if err := parseFlags(fs, args, 0, 1); err != nil {
+		return err
+	}
+
+	formatOpt, err := optionFlags.formatOption(*mode)
+	if err != nil {
+		return usageErrorf(fs, "%v", err)
+	}
+	if *parallel < 1 {
+		return usageErrorf(fs, "-parallel must be positive: %v", *parallel)
+	}
+
+	r := io.Reader(os.Stdin)
+	if name := fs.Arg(0); name != "" && name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	p := &batchProcessor{
+		path:      splitPath(*path),
+		field:     *field,
+		printOnly: *printOnly,
+		inputKind: *inputKind,
+		formatOpt: formatOpt,
+	}
+	return p.run(r, os.Stdout, os.Stderr, *parallel)
+}
+
+// batchProcessor formats types in NDJSON lines.
+type batchProcessor struct {
+	path      []string
+	field     string
+	printOnly bool
+	inputKind string
+	formatOpt spantype.FormatOption
+}
+
+// batchResult is the output of a line. If err is not nil, the line is written as is.
+type batchResult struct {
+	out []byte
+	err error
+}
+
+// run processes lines concurrently and writes the results in the input order.
+// Lines which can't be processed are reported to errw, and it returns errFindings if there are any.
+func (p *batchProcessor) run(r io.Reader, w, errw io.Writer, parallel int) error {
+	// Results are queued in the input order, and the buffer of the queue bounds the number of lines in flight.
+	queue := make(chan chan batchResult, parallel)
+	var readErr error
+	go func() {
+		defer close(queue)
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+		sem := make(chan struct{}, parallel)
+		var wg sync.WaitGroup
+		for scanner.Scan() {
+			line := bytes.Clone(scanner.Bytes())
+			result := make(chan batchResult, 1)
+			queue <- result
+			sem <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer func() { <-sem; wg.Done() }()
+				out, err := p.processLine(line)
+				if err != nil {
+					out = line
+				}
+				result <- batchResult{out: out, err: err}
+			}()
+		}
+		wg.Wait()
+		readErr = scanner.Err()
+	}()
+
+	bw := bufio.NewWriter(w)
+	var failed bool
+	var lineNum int
+	for result := range queue {
+		lineNum++
+		res := <-result
+		if res.err != nil {
+			failed = true
+			fmt.Fprintf(errw, "line %d: %v\n", lineNum, res.err)
+			if p.printOnly {
+				// Keep line correspondence with the input.
+				res.out = nil
+			}
+		}
+		bw.Write(res.out)
+		bw.WriteByte('\n')
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+
+	switch {
+	case readErr != nil:
+		return readErr
+	case failed:
+		return errFindings
+	default:
+		return nil
+	}
+}
+
+// processLine returns the line with the formatted type, or the formatted type itself with printOnly.
+func (p *batchProcessor) processLine(line []byte) ([]byte, error) {
+	trimmed := bytes.TrimSpace(line)
+	if len(trimmed) == 0 {
+		return line, nil
+	}
+
+	target, err := extractPath(trimmed, p.path)
+	if err != nil {
+		return nil, err
+	}
+
+	in, err := decodeInput(target, p.inputKind, inputFormatJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	formatted, err := formatInputLine(in, p.formatOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.printOnly {
+		return []byte(formatted), nil
+	}
+	return addField(trimmed, p.field, formatted)
+}
+
+// splitPath splits a dot-separated JSON path.
+func splitPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, ".")
+}
+
+// extractPath returns the JSON value at the path. Numeric elements are indexes of arrays.
+func extractPath(b []byte, path []string) ([]byte, error) {
+	for i, key := range path {
+		trimmed := bytes.TrimSpace(b)
+		if len(trimmed) > 0 && trimmed[0] == '[' {
+			var arr []json.RawMessage
+			if err := json.Unmarshal(trimmed, &arr); err != nil {
+				return nil, err
+			}
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(arr) {
+				return nil, fmt.Errorf("invalid index %q in path %v", key, strings.Join(path[:i+1], "."))
+			}
+			b = arr[index]
+			continue
+		}
+
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(trimmed, &obj); err != nil {
+			return nil, fmt.Errorf("%v is not an object: %w", strings.Join(path[:i], "."), err)
+		}
+		v, ok := obj[key]
+		if !ok {
+			return nil, fmt.Errorf("no %v in path %v", key, strings.Join(path[:i+1], "."))
+		}
+		b = v
+	}
+	return b, nil
+}
+
+// addField adds `"field": "value"` to the end of the JSON object without reordering other fields.
+// If the object already has the field, its value is replaced in place.
+func addField(obj []byte, field, value string) ([]byte, error) {
+	if len(obj) < 2 || obj[0] != '{' || obj[len(obj)-1] != '}' {
+		return nil, fmt.Errorf("line is not a JSON object")
+	}
+	key, err := marshalString(field)
+	if err != nil {
+		return nil, err
+	}
+	val, err := marshalString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	spans, err := findFieldValues(obj, field)
+	if err != nil {
+		return nil, err
+	}
+	if len(spans) > 0 {
+		out := make([]byte, 0, len(obj)+len(val))
+		var last int
+		for _, span := range spans {
+			out = append(out, obj[last:span[0]]...)
+			out = append(out, val...)
+			last = span[1]
+		}
+		return append(out, obj[last:]...), nil
+	}
+
+	body := bytes.TrimSpace(obj[1 : len(obj)-1])
+	out := make([]byte, 0, len(obj)+len(key)+len(val)+2)
+	out = append(out, '{')
+	out = append(out, body...)
+	if len(body) > 0 {
+		out = append(out, ',')
+	}
+	out = append(out, key...)
+	out = append(out, ':')
+	out = append(out, val...)
+	return append(out, '}'), nil
+}
+
+// findFieldValues returns the byte ranges of the values of the top-level field in the JSON object.
+// There can be more than one range because JSON objects may have duplicate keys.
+func findFieldValues(obj []byte, field string) ([][2]int, error) {
+	dec := json.NewDecoder(bytes.NewReader(obj))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	var spans [][2]int
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		if tok == field {
+			end := int(dec.InputOffset())
+			spans = append(spans, [2]int{end - len(raw), end})
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return spans, nil
+}
+
+// marshalString encodes the string as JSON without escaping `<` and `>`, which are common in types.
+func marshalString(s string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
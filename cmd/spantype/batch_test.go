@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/apstndb/spantype"
+)
+
+// batchInput returns NDJSON lines whose types have varying sizes so that lines finish out of order,
+// with an invalid line every 7 lines.
+func batchInput(n int) (input string, wantOut, wantPrint, wantErr []string) {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		if i%7 == 3 {
+			line := fmt.Sprintf(`{"i":%d,"u":{"code":"INT64"}}`, i)
+			sb.WriteString(line + "\n")
+			wantOut = append(wantOut, line)
+			wantPrint = append(wantPrint, "")
+			wantErr = append(wantErr, fmt.Sprintf("line %d: ", i+1))
+			continue
+		}
+		typ, formatted := `{"code":"INT64"}`, "INT64"
+		for j := 0; j < i%13; j++ {
+			typ = `{"code":"ARRAY","arrayElementType":{"code":"STRUCT","structType":{"fields":[{"name":"n","type":` + typ + `}]}}}`
+			formatted = "ARRAY<STRUCT<n " + formatted + ">>"
+		}
+		sb.WriteString(fmt.Sprintf(`{"i":%d,"t":%v}`+"\n", i, typ))
+		wantOut = append(wantOut, fmt.Sprintf(`{"i":%d,"t":%v,"spantype":%q}`, i, typ, formatted))
+		wantPrint = append(wantPrint, formatted)
+	}
+	return sb.String(), wantOut, wantPrint, wantErr
+}
+
+func TestBatchProcessorRun(t *testing.T) {
+	input, wantOut, wantPrint, wantErr := batchInput(500)
+	for _, printOnly := range []bool{false, true} {
+		for _, parallel := range []int{1, 8, 64} {
+			t.Run(fmt.Sprintf("print=%v,parallel=%v", printOnly, parallel), func(t *testing.T) {
+				p := &batchProcessor{path: []string{"t"}, field: "spantype", printOnly: printOnly, inputKind: inputAuto, formatOpt: spantype.FormatOptionVerbose}
+				var out, errOut bytes.Buffer
+				if err := p.run(strings.NewReader(input), &out, &errOut, parallel); !errors.Is(err, errFindings) {
+					t.Errorf("run want: %v, got: %v", errFindings, err)
+				}
+
+				want := wantOut
+				if printOnly {
+					want = wantPrint
+				}
+				got := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+				if len(want) != len(got) {
+					t.Fatalf("run want: %v lines, got: %v lines", len(want), len(got))
+				}
+				for i := range want {
+					if want[i] != got[i] {
+						t.Errorf("line %d want: %v, got: %v", i+1, want[i], got[i])
+					}
+				}
+
+				errLines := strings.Split(strings.TrimSuffix(errOut.String(), "\n"), "\n")
+				if len(wantErr) != len(errLines) {
+					t.Fatalf("errors want: %v lines, got: %q", len(wantErr), errLines)
+				}
+				for i := range wantErr {
+					if !strings.HasPrefix(errLines[i], wantErr[i]) {
+						t.Errorf("error want prefix: %v, got: %v", wantErr[i], errLines[i])
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestBatchProcessorRunNoFailures(t *testing.T) {
+	p := &batchProcessor{field: "type", inputKind: inputAuto, formatOpt: spantype.FormatOptionSimple}
+	input := `{"code":"INT64"}` + "\n\n" + `{"fields":[{"name":"n","type":{"code":"STRING"}}]}` + "\n"
+	var out, errOut bytes.Buffer
+	if err := p.run(strings.NewReader(input), &out, &errOut, 4); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	want := `{"code":"INT64","type":"INT64"}` + "\n\n" + `{"fields":[{"name":"n","type":{"code":"STRING"}}],"type":"STRING"}` + "\n"
+	if want != out.String() {
+		t.Errorf("run want: %v, got: %v", want, out.String())
+	}
+	if errOut.Len() > 0 {
+		t.Errorf("run want no errors, got: %v", errOut.String())
+	}
+}
+
+func TestExtractPath(t *testing.T) {
+	const doc = `{"a": {"b": [{"c": 1}, {"c": [2, 3]}]}, "s": "x"}`
+	tests := []struct {
+		desc    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{desc: "empty path", path: "", want: doc},
+		{desc: "object", path: "a.b.0", want: `{"c": 1}`},
+		{desc: "nested array", path: "a.b.1.c.1", want: `3`},
+		{desc: "missing key", path: "a.x", wantErr: true},
+		{desc: "index out of range", path: "a.b.2", wantErr: true},
+		{desc: "negative index", path: "a.b.-1", wantErr: true},
+		{desc: "non-numeric index", path: "a.b.c", wantErr: true},
+		{desc: "not an object", path: "s.t", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := extractPath([]byte(doc), splitPath(tt.path))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("extractPath should fail, but got: %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("extractPath failed: %v", err)
+			}
+			if tt.want != string(got) {
+				t.Errorf("extractPath want: %v, got: %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAddField(t *testing.T) {
+	tests := []struct {
+		desc    string
+		obj     string
+		want    string
+		wantErr bool
+	}{
+		{desc: "append", obj: `{"a":1}`, want: `{"a":1,"spantype":"ARRAY<STRING>"}`},
+		{desc: "empty object", obj: `{ }`, want: `{"spantype":"ARRAY<STRING>"}`},
+		{desc: "replace existing field", obj: `{"spantype":"old","a":1}`, want: `{"spantype":"ARRAY<STRING>","a":1}`},
+		{desc: "replace non-string value", obj: `{"a":1, "spantype": {"x": [1, 2]} }`, want: `{"a":1, "spantype": "ARRAY<STRING>" }`},
+		{desc: "replace duplicate keys", obj: `{"spantype":1,"spantype":2}`, want: `{"spantype":"ARRAY<STRING>","spantype":"ARRAY<STRING>"}`},
+		{desc: "escaped key", obj: `{"spant\u0079pe":1}`, want: `{"spant\u0079pe":"ARRAY<STRING>"}`},
+		{desc: "nested field is not replaced", obj: `{"a":{"spantype":1}}`, want: `{"a":{"spantype":1},"spantype":"ARRAY<STRING>"}`},
+		{desc: "not an object", obj: `[1]`, wantErr: true},
+		{desc: "invalid JSON", obj: `{"a":}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := addField([]byte(tt.obj), "spantype", "ARRAY<STRING>")
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("addField should fail, but got: %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("addField failed: %v", err)
+			}
+			if tt.want != string(got) {
+				t.Errorf("addField want: %v, got: %s", tt.want, got)
+			}
+		})
+	}
+}
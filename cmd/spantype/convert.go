@@ -0,0 +1,427 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype"
+)
+
+func runConvert(args []string) error {
+	fs := newFlagSet("convert", "[FILE]", "convert converts a row type in protobuf JSON, binary, or text format read from FILE or stdin to another schema language.\n"+
+		"jsonschema and ts describe values in the JSON encoding of Spanner, in which rows and STRUCTs are arrays.")
+	to := fs.String("to", "", "output format (jsonschema|ts|go|avro|ddl)")
+	name := fs.String("name", "Row", "name of the generated type, or the table name for ddl")
+	inputs := addInputFlags(fs)
+	dialectStr := fs.String("dialect", "googlesql", "DDL dialect for ddl (googlesql|postgresql)")
+	primaryKey := fs.String("primary-key", "", "comma-separated primary key columns for ddl")
+	if err := parseFlags(fs, args, 0, 1); err != nil {
+		return err
+	}
+
+	dialect, err := dialectFromString(*dialectStr)
+	if err != nil {
+		return usageErrorf(fs, "%v", err)
+	}
+
+	var convert func(name string, fields []*sppb.StructType_Field) (string, error)
+	switch *to {
+	case "jsonschema":
+		convert = convertJSONSchema
+	case "ts":
+		convert = convertTypeScript
+	case "go":
+		convert = convertGo
+	case "avro":
+		convert = convertAvro
+	case "ddl":
+		convert = func(name string, fields []*sppb.StructType_Field) (string, error) {
+			var pk []string
+			if *primaryKey != "" {
+				pk = strings.Split(*primaryKey, ",")
+			}
+			return spantype.FormatCreateTable(name, &sppb.StructType{Fields: fields}, spantype.DDLOption{Dialect: dialect, PrimaryKey: pk})
+		}
+	case "":
+		return usageErrorf(fs, "-to is required")
+	default:
+		return usageErrorf(fs, "unknown -to: %v", *to)
+	}
+
+	b, err := readFileOrStdin(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	in, err := inputs.decode(b)
+	if err != nil {
+		return err
+	}
+
+	fields := in.fields
+	switch {
+	case in.kind == inputParams:
+		return fmt.Errorf("convert doesn't support param types")
+	case in.kind == inputType && in.typ.GetCode() != sppb.TypeCode_STRUCT:
+		return fmt.Errorf("convert requires a row type or STRUCT type, but %v", spantype.FormatTypeMoreVerbose(in.typ))
+	case in.kind == inputType:
+		fields = in.typ.GetStructType().GetFields()
+	}
+
+	s, err := convert(*name, fields)
+	if err != nil {
+		return err
+	}
+	fmt.Println(s)
+	return nil
+}
+
+// convertJSONSchema converts the row type to JSON Schema (draft 2020-12) of a row in the JSON encoding.
+func convertJSONSchema(name string, fields []*sppb.StructType_Field) (string, error) {
+	schema := jsonSchemaFields(fields)
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = name
+	schema["type"] = "array"
+	b, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func jsonSchemaFields(fields []*sppb.StructType_Field) map[string]any {
+	items := make([]any, 0, len(fields))
+	for _, field := range fields {
+		schema := jsonSchemaType(field.GetType())
+		if field.GetName() != "" {
+			schema["title"] = field.GetName()
+		}
+		items = append(items, schema)
+	}
+	return map[string]any{
+		"prefixItems": items,
+		"minItems":    len(fields),
+		"items":       false,
+	}
+}
+
+// jsonSchemaType returns the schema of a nullable value of the type.
+func jsonSchemaType(typ *sppb.Type) map[string]any {
+	nullable := func(typ string) []string { return []string{typ, "null"} }
+	switch typ.GetCode() {
+	case sppb.TypeCode_BOOL:
+		return map[string]any{"type": nullable("boolean")}
+	case sppb.TypeCode_INT64, sppb.TypeCode_ENUM:
+		return map[string]any{"type": nullable("string"), "pattern": "^-?[0-9]+$"}
+	case sppb.TypeCode_FLOAT32, sppb.TypeCode_FLOAT64:
+		// NaN and infinities are encoded as strings.
+		return map[string]any{"type": []string{"number", "string", "null"}}
+	case sppb.TypeCode_TIMESTAMP:
+		return map[string]any{"type": nullable("string"), "format": "date-time"}
+	case sppb.TypeCode_DATE:
+		return map[string]any{"type": nullable("string"), "format": "date"}
+	case sppb.TypeCode_UUID:
+		return map[string]any{"type": nullable("string"), "format": "uuid"}
+	case sppb.TypeCode_BYTES, sppb.TypeCode_PROTO:
+		return map[string]any{"type": nullable("string"), "contentEncoding": "base64"}
+	case sppb.TypeCode_JSON:
+		return map[string]any{"type": nullable("string"), "contentMediaType": "application/json"}
+	case sppb.TypeCode_ARRAY:
+		return map[string]any{"type": nullable("array"), "items": jsonSchemaType(typ.GetArrayElementType())}
+	case sppb.TypeCode_STRUCT:
+		schema := jsonSchemaFields(typ.GetStructType().GetFields())
+		schema["type"] = nullable("array")
+		return schema
+	default:
+		// STRING, NUMERIC, INTERVAL, and unknown types are encoded as strings.
+		return map[string]any{"type": nullable("string")}
+	}
+}
+
+// convertTypeScript converts the row type to a TypeScript type of a row in the JSON encoding.
+func convertTypeScript(name string, fields []*sppb.StructType_Field) (string, error) {
+	if !isTSIdentifier(name) {
+		return "", fmt.Errorf("invalid TypeScript type name: %v", name)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "export type %v = [\n", name)
+	labeled := isLabeledTuple(fields)
+	for _, field := range fields {
+		sb.WriteString("  ")
+		if labeled {
+			sb.WriteString(field.GetName() + ": ")
+		}
+		sb.WriteString(tsType(field.GetType()) + " | null,\n")
+	}
+	sb.WriteString("];")
+	return sb.String(), nil
+}
+
+// isLabeledTuple reports whether all fields can be tuple labels. TypeScript requires all or none of elements are labeled.
+func isLabeledTuple(fields []*sppb.StructType_Field) bool {
+	for _, field := range fields {
+		if !isTSIdentifier(field.GetName()) {
+			return false
+		}
+	}
+	return len(fields) > 0
+}
+
+// tsReservedWords is the reserved words of JavaScript, which can't be TypeScript identifiers.
+var tsReservedWords = map[string]bool{
+	"break": true, "case": true, "catch": true, "class": true, "const": true, "continue": true,
+	"debugger": true, "default": true, "delete": true, "do": true, "else": true, "enum": true,
+	"export": true, "extends": true, "false": true, "finally": true, "for": true, "function": true,
+	"if": true, "import": true, "in": true, "instanceof": true, "new": true, "null": true,
+	"return": true, "super": true, "switch": true, "this": true, "throw": true, "true": true,
+	"try": true, "typeof": true, "var": true, "void": true, "while": true, "with": true,
+}
+
+// isTSIdentifier reports whether the name is a TypeScript identifier. Non-ASCII identifiers are conservatively rejected.
+func isTSIdentifier(name string) bool {
+	if name == "" || tsReservedWords[name] {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_' || r == '$' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z':
+		case '0' <= r && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// tsType returns the TypeScript type of a non-null value of the type.
+func tsType(typ *sppb.Type) string {
+	switch typ.GetCode() {
+	case sppb.TypeCode_BOOL:
+		return "boolean"
+	case sppb.TypeCode_FLOAT32, sppb.TypeCode_FLOAT64:
+		return `number | "NaN" | "Infinity" | "-Infinity"`
+	case sppb.TypeCode_ARRAY:
+		return "(" + tsType(typ.GetArrayElementType()) + " | null)[]"
+	case sppb.TypeCode_STRUCT:
+		fields := typ.GetStructType().GetFields()
+		labeled := isLabeledTuple(fields)
+		elems := make([]string, 0, len(fields))
+		for _, field := range fields {
+			elem := tsType(field.GetType()) + " | null"
+			if labeled {
+				elem = field.GetName() + ": " + elem
+			}
+			elems = append(elems, elem)
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	default:
+		return "string"
+	}
+}
+
+// convertGo converts the row type to a Go struct type for cloud.google.com/go/spanner.Row.ToStruct.
+func convertGo(name string, fields []*sppb.StructType_Field) (string, error) {
+	if !token.IsIdentifier(name) {
+		return "", fmt.Errorf("invalid Go type name: %v", name)
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "type %v struct {\n", name)
+	writeGoFields(&buf, fields)
+	buf.WriteString("}\n")
+
+	b, err := format.Source(buf.Bytes())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(b), "\n"), nil
+}
+
+func writeGoFields(buf *bytes.Buffer, fields []*sppb.StructType_Field) {
+	used := make(map[string]bool)
+	for i, field := range fields {
+		if field.GetName() == "" {
+			// Unnamed columns can't be decoded into struct fields.
+			fmt.Fprintf(buf, "// column %d is unnamed\n", i)
+			continue
+		}
+		name := goFieldName(field.GetName(), i)
+		for used[name] {
+			name += "_"
+		}
+		used[name] = true
+		fmt.Fprintf(buf, "%v %v `spanner:%v`\n", name, goType(field.GetType()), strconv.Quote(field.GetName()))
+	}
+}
+
+// goFieldName converts the column name to an exported Go identifier. e.g. `user_id` to `UserId`
+func goFieldName(name string, i int) string {
+	var sb strings.Builder
+	upper := true
+	for _, r := range name {
+		switch {
+		case r == '_' || !(unicode.IsLetter(r) || unicode.IsDigit(r)):
+			upper = true
+		case upper:
+			sb.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	s := sb.String()
+	if s == "" || !token.IsExported(s) {
+		return "Field" + strconv.Itoa(i) + s
+	}
+	return s
+}
+
+// goType returns the Go type which can hold a nullable value of the type.
+func goType(typ *sppb.Type) string {
+	switch typ.GetCode() {
+	case sppb.TypeCode_BOOL:
+		return "spanner.NullBool"
+	case sppb.TypeCode_INT64, sppb.TypeCode_ENUM:
+		return "spanner.NullInt64"
+	case sppb.TypeCode_FLOAT32:
+		return "spanner.NullFloat32"
+	case sppb.TypeCode_FLOAT64:
+		return "spanner.NullFloat64"
+	case sppb.TypeCode_STRING:
+		return "spanner.NullString"
+	case sppb.TypeCode_BYTES, sppb.TypeCode_PROTO:
+		return "[]byte"
+	case sppb.TypeCode_TIMESTAMP:
+		return "spanner.NullTime"
+	case sppb.TypeCode_DATE:
+		return "spanner.NullDate"
+	case sppb.TypeCode_NUMERIC:
+		if typ.GetTypeAnnotation() == sppb.TypeAnnotationCode_PG_NUMERIC {
+			return "spanner.PGNumeric"
+		}
+		return "spanner.NullNumeric"
+	case sppb.TypeCode_JSON:
+		if typ.GetTypeAnnotation() == sppb.TypeAnnotationCode_PG_JSONB {
+			return "spanner.PGJsonB"
+		}
+		return "spanner.NullJSON"
+	case sppb.TypeCode_ARRAY:
+		elem := typ.GetArrayElementType()
+		if elem.GetCode() == sppb.TypeCode_STRUCT {
+			var buf bytes.Buffer
+			buf.WriteString("[]*struct {\n")
+			writeGoFields(&buf, elem.GetStructType().GetFields())
+			buf.WriteString("}")
+			return buf.String()
+		}
+		return "[]" + goType(elem)
+	default:
+		// UUID, INTERVAL, and non-array STRUCT are not supported by the struct decoder.
+		return "spanner.GenericColumnValue"
+	}
+}
+
+// avroField is a field of Avro record. The default is always null because all fields are nullable.
+type avroField struct {
+	Name    string `json:"name"`
+	Type    any    `json:"type"`
+	Default any    `json:"default"`
+}
+
+type avroRecord struct {
+	Type   string      `json:"type"`
+	Name   string      `json:"name"`
+	Fields []avroField `json:"fields"`
+}
+
+type avroArray struct {
+	Type  string `json:"type"`
+	Items any    `json:"items"`
+}
+
+// convertAvro converts the row type to an Avro record schema. All fields are nullable.
+func convertAvro(name string, fields []*sppb.StructType_Field) (string, error) {
+	b, err := json.MarshalIndent(avroFields(name, fields), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func avroFields(name string, fields []*sppb.StructType_Field) avroRecord {
+	record := avroRecord{Type: "record", Name: avroName(name, 0), Fields: []avroField{}}
+	used := make(map[string]bool)
+	for i, field := range fields {
+		fieldName := avroName(field.GetName(), i)
+		for used[fieldName] {
+			fieldName += "_"
+		}
+		used[fieldName] = true
+		record.Fields = append(record.Fields, avroField{
+			Name: fieldName,
+			// Nested record names must be unique in the schema.
+			Type: []any{"null", avroType(record.Name+"_"+fieldName, field.GetType())},
+		})
+	}
+	return record
+}
+
+// avroName converts the name to a valid Avro name. e.g. `_0` for an unnamed field
+func avroName(name string, i int) string {
+	var sb strings.Builder
+	for j, r := range name {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || r == '_'):
+			sb.WriteRune(r)
+		case r < unicode.MaxASCII && unicode.IsDigit(r) && j > 0:
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune('_')
+		}
+	}
+	if sb.Len() == 0 {
+		return "_" + strconv.Itoa(i)
+	}
+	return sb.String()
+}
+
+// avroType returns the Avro schema of a non-null value of the type. name is used for records.
+func avroType(name string, typ *sppb.Type) any {
+	switch typ.GetCode() {
+	case sppb.TypeCode_BOOL:
+		return "boolean"
+	case sppb.TypeCode_INT64, sppb.TypeCode_ENUM:
+		return "long"
+	case sppb.TypeCode_FLOAT32:
+		return "float"
+	case sppb.TypeCode_FLOAT64:
+		return "double"
+	case sppb.TypeCode_BYTES, sppb.TypeCode_PROTO:
+		return "bytes"
+	case sppb.TypeCode_TIMESTAMP:
+		return map[string]any{"type": "long", "logicalType": "timestamp-micros"}
+	case sppb.TypeCode_DATE:
+		return map[string]any{"type": "int", "logicalType": "date"}
+	case sppb.TypeCode_UUID:
+		return map[string]any{"type": "string", "logicalType": "uuid"}
+	case sppb.TypeCode_NUMERIC:
+		if typ.GetTypeAnnotation() == sppb.TypeAnnotationCode_PG_NUMERIC {
+			// PostgreSQL numeric has arbitrary precision.
+			return "string"
+		}
+		return map[string]any{"type": "bytes", "logicalType": "decimal", "precision": 38, "scale": 9}
+	case sppb.TypeCode_ARRAY:
+		return avroArray{Type: "array", Items: []any{"null", avroType(name, typ.GetArrayElementType())}}
+	case sppb.TypeCode_STRUCT:
+		return avroFields(name, typ.GetStructType().GetFields())
+	default:
+		// STRING, JSON, INTERVAL, and unknown types are strings.
+		return "string"
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype"
+
+	. "github.com/apstndb/spantype/typector"
+)
+
+// nestedRowType has unnamed fields, nested ARRAY<STRUCT>, and annotated types.
+var nestedRowType = []*sppb.StructType_Field{
+	F("user_id", Int64()),
+	TypeToUnnamedStructTypeField(String()),
+	F("tags", Array(String())),
+	F("items", Array(Struct(
+		F("n", Int64()),
+		TypeToUnnamedStructTypeField(Float64()),
+		F("inner", Array(Struct(F("s", String()), F("b", Bytes())))),
+	))),
+	F("st", Struct(F("a", Bool()), TypeToUnnamedStructTypeField(Date()))),
+	F("book", Proto("examples.Book")),
+	F("genre", Enum("examples.Genre")),
+	F("price", PGNumeric()),
+	F("doc", PGJSONB()),
+	F("created_at", Timestamp()),
+	F("f32", Float32()),
+	F("uuid", UUID()),
+}
+
+// tableRowType consists of types which are allowed as columns.
+var tableRowType = []*sppb.StructType_Field{
+	F("UserId", Int64()),
+	F("Name", String()),
+	F("Tags", Array(String())),
+	F("Book", Proto("examples.Book")),
+	F("Score", Numeric()),
+	F("select", Bool()),
+}
+
+func TestConvert(t *testing.T) {
+	ddl := func(dialect spantype.Dialect) func(name string, fields []*sppb.StructType_Field) (string, error) {
+		return func(name string, fields []*sppb.StructType_Field) (string, error) {
+			return spantype.FormatCreateTable(name, &sppb.StructType{Fields: fields}, spantype.DDLOption{Dialect: dialect, PrimaryKey: []string{"UserId"}})
+		}
+	}
+
+	tests := []struct {
+		golden  string
+		convert func(name string, fields []*sppb.StructType_Field) (string, error)
+		fields  []*sppb.StructType_Field
+	}{
+		{"convert/nested.jsonschema.golden", convertJSONSchema, nestedRowType},
+		{"convert/nested.ts.golden", convertTypeScript, nestedRowType},
+		{"convert/nested.go.golden", convertGo, nestedRowType},
+		{"convert/nested.avro.golden", convertAvro, nestedRowType},
+		{"convert/labeled.ts.golden", convertTypeScript, tableRowType},
+		{"convert/table.go.golden", convertGo, tableRowType},
+		{"convert/table.googlesql.golden", ddl(spantype.DialectGoogleSQL), tableRowType[:5]},
+		{"convert/table.postgresql.golden", ddl(spantype.DialectPostgreSQL), append(tableRowType[:3:3], tableRowType[4:]...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			got, err := tt.convert("Row", tt.fields)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.golden, got+"\n")
+		})
+	}
+}
+
+func TestConvertError(t *testing.T) {
+	tests := []struct {
+		desc    string
+		convert func(name string, fields []*sppb.StructType_Field) (string, error)
+		name    string
+		fields  []*sppb.StructType_Field
+	}{
+		{"invalid TypeScript name", convertTypeScript, "my-row", tableRowType},
+		{"reserved TypeScript name", convertTypeScript, "default", tableRowType},
+		{"invalid Go name", convertGo, "1Row", tableRowType},
+		{"STRUCT column", func(name string, fields []*sppb.StructType_Field) (string, error) {
+			return spantype.FormatCreateTable(name, &sppb.StructType{Fields: fields}, spantype.DDLOption{})
+		}, "Row", nestedRowType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got, err := tt.convert(tt.name, tt.fields); err == nil {
+				t.Errorf("convert should fail, but got: %v", got)
+			}
+		})
+	}
+}
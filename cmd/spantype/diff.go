@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype"
+)
+
+func runDiff(args []string) error {
+	fs := newFlagSet("diff", "FILE1 FILE2", "diff compares types in two protobuf JSON, binary, or text format files, and exits with 1 if they differ.\n"+
+		"STRUCT fields are compared by position.")
+	inputs := addInputFlags(fs)
+	if err := parseFlags(fs, args, 2, 2); err != nil {
+		return err
+	}
+
+	var ins [2]*input
+	for i := range ins {
+		b, err := os.ReadFile(fs.Arg(i))
+		if err != nil {
+			return err
+		}
+		if ins[i], err = inputs.decode(b); err != nil {
+			return fmt.Errorf("%v: %w", fs.Arg(i), err)
+		}
+	}
+
+	d := &differ{w: os.Stdout}
+	switch a, b := ins[0], ins[1]; {
+	case a.kind == inputType && b.kind == inputType:
+		d.diffType(nil, a.typ, b.typ)
+	case a.kind == inputParams && b.kind == inputParams:
+		d.diffParams(a.params, b.params)
+	case a.kind != inputType && a.kind != inputParams && b.kind != inputType && b.kind != inputParams:
+		d.diffFields(nil, a.fields, b.fields)
+	default:
+		return fmt.Errorf("can't compare different input kinds")
+	}
+
+	if d.differs {
+		return errFindings
+	}
+	return nil
+}
+
+// differ prints differences of types as `-` and `+` lines.
+type differ struct {
+	w       io.Writer
+	differs bool
+}
+
+func (d *differ) printf(format string, args ...any) {
+	d.differs = true
+	fmt.Fprintf(d.w, format, args...)
+}
+
+func (d *differ) diffParams(a, b map[string]*sppb.Type) {
+	for _, name := range sortedKeys(a) {
+		if _, ok := b[name]; !ok {
+			d.printf("- @%v %v\n", name, spantype.FormatTypeMoreVerbose(a[name]))
+			continue
+		}
+		d.diffTypeWithPrefix("@"+name, nil, a[name], b[name])
+	}
+	for _, name := range sortedKeys(b) {
+		if _, ok := a[name]; !ok {
+			d.printf("+ @%v %v\n", name, spantype.FormatTypeMoreVerbose(b[name]))
+		}
+	}
+}
+
+func (d *differ) diffType(path spantype.Path, a, b *sppb.Type) {
+	d.diffTypeWithPrefix("", path, a, b)
+}
+
+// diffTypeWithPrefix compares types at the path. prefix is prepended to the path, e.g. a parameter name.
+func (d *differ) diffTypeWithPrefix(prefix string, path spantype.Path, a, b *sppb.Type) {
+	if a.GetCode() != b.GetCode() || a.GetTypeAnnotation() != b.GetTypeAnnotation() || a.GetProtoTypeFqn() != b.GetProtoTypeFqn() {
+		loc := location(prefix, path)
+		d.printf("- %v%v\n+ %v%v\n", loc, spantype.FormatTypeMoreVerbose(a), loc, spantype.FormatTypeMoreVerbose(b))
+		return
+	}
+
+	switch a.GetCode() {
+	case sppb.TypeCode_ARRAY:
+		d.diffTypeWithPrefix(prefix, path.Append(spantype.PathElem{Elem: true}), a.GetArrayElementType(), b.GetArrayElementType())
+	case sppb.TypeCode_STRUCT:
+		d.diffFieldsWithPrefix(prefix, path, a.GetStructType().GetFields(), b.GetStructType().GetFields())
+	}
+}
+
+func (d *differ) diffFields(path spantype.Path, a, b []*sppb.StructType_Field) {
+	d.diffFieldsWithPrefix("", path, a, b)
+}
+
+func (d *differ) diffFieldsWithPrefix(prefix string, path spantype.Path, a, b []*sppb.StructType_Field) {
+	for i := range max(len(a), len(b)) {
+		switch {
+		case i >= len(a):
+			d.printf("+ %v%v\n", location(prefix, path.Append(fieldElem(i, b[i]))), spantype.FormatTypeMoreVerbose(b[i].GetType()))
+		case i >= len(b):
+			d.printf("- %v%v\n", location(prefix, path.Append(fieldElem(i, a[i]))), spantype.FormatTypeMoreVerbose(a[i].GetType()))
+		case a[i].GetName() != b[i].GetName():
+			d.printf("- %v%v\n+ %v%v\n",
+				location(prefix, path.Append(fieldElem(i, a[i]))), spantype.FormatTypeMoreVerbose(a[i].GetType()),
+				location(prefix, path.Append(fieldElem(i, b[i]))), spantype.FormatTypeMoreVerbose(b[i].GetType()))
+		default:
+			d.diffTypeWithPrefix(prefix, path.Append(fieldElem(i, a[i])), a[i].GetType(), b[i].GetType())
+		}
+	}
+}
+
+// location formats the prefix and the path followed by a space, or an empty string for the root.
+func location(prefix string, path spantype.Path) string {
+	s := path.String()
+	switch {
+	case prefix != "" && s != "" && !path[0].Elem:
+		s = prefix + "." + s
+	default:
+		s = prefix + s
+	}
+	if s == "" {
+		return ""
+	}
+	return s + " "
+}
+
+func fieldElem(i int, field *sppb.StructType_Field) spantype.PathElem {
+	return spantype.PathElem{Name: field.GetName(), Index: i}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/apstndb/spantype"
+)
+
+func runFormat(args []string) error {
+	fs := newFlagSet("format", "[FILE]", "format formats types in protobuf JSON, binary, or text format read from FILE or stdin.")
+	mode := fs.String("mode", "verbose", "format mode (simplest|simple|normal|verbose|more|go), or a spantype.ParseFormatOption specification")
+	typectorName := fs.String("typector-name", "typector", "package name of typector in --mode=go, or \".\" for dot import")
+	inputs := addInputFlags(fs)
+	optionFlags := addFormatOptionFlags(fs)
+	if err := parseFlags(fs, args, 0, 1); err != nil {
+		return err
+	}
+
+	var goOpts *spantype.GoSyntaxOption
+	var formatOpt spantype.FormatOption
+	if strings.ToLower(*mode) == "go" {
+		goOpts = &spantype.GoSyntaxOption{TypectorName: *typectorName, Multiline: true}
+	} else {
+		var err error
+		if formatOpt, err = optionFlags.formatOption(*mode); err != nil {
+			return usageErrorf(fs, "%v", err)
+		}
+	}
+
+	b, err := readFileOrStdin(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	in, err := inputs.decode(b)
+	if err != nil {
+		return err
+	}
+	return printInput(os.Stdout, in, formatOpt, goOpts)
+}
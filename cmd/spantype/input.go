@@ -0,0 +1,394 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+// Input kinds of --input.
+const (
+	inputAuto             = "auto"
+	inputStructType       = "struct"
+	inputType             = "type"
+	inputMetadata         = "metadata"
+	inputResultSet        = "result-set"
+	inputPartialResultSet = "partial-result-set"
+	inputParams           = "params"
+)
+
+var inputKinds = []string{inputAuto, inputStructType, inputType, inputMetadata, inputResultSet, inputPartialResultSet, inputParams}
+
+// input is a decoded input. fields, typ, or params is set depending on kind.
+type input struct {
+	// kind is the input kind, which is never inputAuto.
+	kind string
+	// fields is the row type of StructType, ResultSetMetadata, ResultSet, or PartialResultSet.
+	fields []*sppb.StructType_Field
+	// typ is a single Type.
+	typ *sppb.Type
+	// params is param_types of ExecuteSqlRequest or a bare param_types map.
+	params map[string]*sppb.Type
+}
+
+// detectInputKind guesses the input kind from the top-level keys of the JSON object.
+func detectInputKind(b []byte) (string, error) {
+	if trimmed := bytes.TrimSpace(b); len(trimmed) > 0 && trimmed[0] == '[' {
+		return inputPartialResultSet, nil
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return "", fmt.Errorf("input is not a JSON object: %w", err)
+	}
+	has := func(keys ...string) bool {
+		return slices.ContainsFunc(keys, func(key string) bool {
+			_, ok := obj[key]
+			return ok
+		})
+	}
+
+	switch {
+	case has("values", "chunkedValue", "chunked_value", "resumeToken", "resume_token"):
+		return inputPartialResultSet, nil
+	case has("metadata"):
+		return inputResultSet, nil
+	case has("rowType", "row_type", "undeclaredParameters", "undeclared_parameters"):
+		return inputMetadata, nil
+	case has("paramTypes", "param_types", "sql"):
+		return inputParams, nil
+	case has("fields"):
+		return inputStructType, nil
+	case has("code"):
+		return inputType, nil
+	}
+
+	// A bare param_types map is an object whose values are all Type objects.
+	for _, v := range obj {
+		var typ map[string]json.RawMessage
+		if err := json.Unmarshal(v, &typ); err != nil {
+			return "", fmt.Errorf("unknown input kind; use --input")
+		}
+		if _, ok := typ["code"]; !ok {
+			return "", fmt.Errorf("unknown input kind; use --input")
+		}
+	}
+	if len(obj) == 0 {
+		return "", fmt.Errorf("unknown input kind; use --input")
+	}
+	return inputParams, nil
+}
+
+// Input formats of --input-format.
+const (
+	inputFormatAuto   = "auto"
+	inputFormatJSON   = "json"
+	inputFormatBinary = "binary"
+	inputFormatText   = "text"
+)
+
+var inputFormats = []string{inputFormatAuto, inputFormatJSON, inputFormatBinary, inputFormatText}
+
+// inputFlags are flags of the input kind and format.
+type inputFlags struct {
+	kind   *string
+	format *string
+}
+
+func addInputFlags(fs *flag.FlagSet) *inputFlags {
+	return &inputFlags{
+		kind:   fs.String("input", inputAuto, "input kind ("+strings.Join(inputKinds, "|")+")"),
+		format: fs.String("input-format", inputFormatAuto, "input format ("+strings.Join(inputFormats, "|")+"); binary requires -input"),
+	}
+}
+
+func (f *inputFlags) decode(b []byte) (*input, error) {
+	return decodeInput(b, *f.kind, *f.format)
+}
+
+// detectInputFormat guesses the input format. Binary protobuf almost always has control characters as tags and lengths.
+func detectInputFormat(b []byte) string {
+	if !utf8.Valid(b) || bytes.ContainsFunc(b, func(r rune) bool { return unicode.IsControl(r) && !unicode.IsSpace(r) }) {
+		return inputFormatBinary
+	}
+	if trimmed := bytes.TrimSpace(b); len(trimmed) == 0 || trimmed[0] == '{' || trimmed[0] == '[' {
+		return inputFormatJSON
+	}
+	return inputFormatText
+}
+
+// decodeInput decodes protobuf JSON, binary, or text format of the given kind.
+// When the format is auto, the detected format is tried first, and the other formats are tried if it fails,
+// e.g. text format wrapped in `{}` and binary input which happens to be printable.
+func decodeInput(b []byte, kind, format string) (*input, error) {
+	if format != inputFormatAuto {
+		return decodeInputFormat(b, kind, format)
+	}
+
+	var formats []string
+	switch detected := detectInputFormat(b); detected {
+	case inputFormatBinary:
+		formats = []string{inputFormatBinary}
+	case inputFormatJSON:
+		formats = []string{inputFormatJSON}
+		if !json.Valid(b) {
+			formats = append(formats, inputFormatText, inputFormatBinary)
+		}
+	default:
+		formats = []string{inputFormatText, inputFormatBinary}
+	}
+	var firstErr error
+	for _, format := range formats {
+		if format == inputFormatBinary && kind == inputAuto && firstErr != nil {
+			// Binary input is tried only when the kind is given.
+			continue
+		}
+		in, err := decodeInputFormat(b, kind, format)
+		if err == nil {
+			return in, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+// decodeInputFormat decodes the input in the given format.
+func decodeInputFormat(b []byte, kind, format string) (*input, error) {
+	switch format {
+	case inputFormatJSON:
+		return decodeJSONInput(b, kind)
+	case inputFormatBinary:
+		if kind == inputAuto {
+			return nil, fmt.Errorf("input kind of binary input can't be detected; use --input")
+		}
+		return decodeMessageInput(b, kind, proto.Unmarshal)
+	case inputFormatText:
+		return decodeTextInput(b, kind)
+	default:
+		return nil, fmt.Errorf("unknown input format: %v", format)
+	}
+}
+
+// decodeTextInput decodes protobuf text format of the given kind. Debug dumps wrapped in `{}` are also accepted.
+func decodeTextInput(b []byte, kind string) (*input, error) {
+	in, err := decodeTextInputKind(b, kind)
+	if err == nil {
+		return in, nil
+	}
+	if trimmed := bytes.TrimSpace(b); len(trimmed) >= 2 && trimmed[0] == '{' && trimmed[len(trimmed)-1] == '}' {
+		if inner := bytes.TrimSpace(trimmed[1 : len(trimmed)-1]); len(inner) > 0 {
+			if in, err := decodeTextInputKind(inner, kind); err == nil {
+				return in, nil
+			}
+		}
+	}
+	return nil, err
+}
+
+func decodeTextInputKind(b []byte, kind string) (*input, error) {
+	if kind != inputAuto {
+		return decodeMessageInput(b, kind, prototext.Unmarshal)
+	}
+	// Text format is strict about unknown fields, so the first kind which can be unmarshaled is the kind.
+	for _, kind := range []string{inputType, inputStructType, inputMetadata, inputResultSet, inputPartialResultSet, inputParams} {
+		if in, err := decodeMessageInput(b, kind, prototext.Unmarshal); err == nil {
+			return in, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown input kind; use --input")
+}
+
+// decodeJSONInput decodes protobuf JSON of the given kind.
+func decodeJSONInput(b []byte, kind string) (*input, error) {
+	if kind == inputAuto {
+		var err error
+		if kind, err = detectInputKind(b); err != nil {
+			return nil, err
+		}
+	}
+
+	switch kind {
+	case inputPartialResultSet:
+		return decodePartialResultSets(b)
+	case inputParams:
+		return decodeParams(b)
+	default:
+		return decodeMessageInput(b, kind, protojson.Unmarshal)
+	}
+}
+
+// decodeMessageInput decodes the message of the given kind using unmarshal.
+func decodeMessageInput(b []byte, kind string, unmarshal func([]byte, proto.Message) error) (*input, error) {
+	var m proto.Message
+	switch kind {
+	case inputStructType:
+		m = &sppb.StructType{}
+	case inputType:
+		m = &sppb.Type{}
+	case inputMetadata:
+		m = &sppb.ResultSetMetadata{}
+	case inputResultSet:
+		m = &sppb.ResultSet{}
+	case inputPartialResultSet:
+		m = &sppb.PartialResultSet{}
+	case inputParams:
+		m = &sppb.ExecuteSqlRequest{}
+	default:
+		return nil, fmt.Errorf("unknown input kind: %v", kind)
+	}
+	if err := unmarshal(b, m); err != nil {
+		return nil, err
+	}
+
+	switch m := m.(type) {
+	case *sppb.StructType:
+		return &input{kind: kind, fields: m.GetFields()}, nil
+	case *sppb.Type:
+		return &input{kind: kind, typ: m}, nil
+	case *sppb.ResultSetMetadata:
+		return &input{kind: kind, fields: m.GetRowType().GetFields()}, nil
+	case *sppb.ResultSet:
+		return &input{kind: kind, fields: m.GetMetadata().GetRowType().GetFields()}, nil
+	case *sppb.PartialResultSet:
+		if m.GetMetadata() == nil {
+			return nil, fmt.Errorf("PartialResultSet has no metadata")
+		}
+		return &input{kind: kind, fields: m.GetMetadata().GetRowType().GetFields()}, nil
+	default:
+		return &input{kind: kind, params: m.(*sppb.ExecuteSqlRequest).GetParamTypes()}, nil
+	}
+}
+
+// decodePartialResultSets decodes a PartialResultSet or an array of PartialResultSets of a stream.
+// Only the first PartialResultSet of a stream has metadata.
+func decodePartialResultSets(b []byte) (*input, error) {
+	var raws []json.RawMessage
+	if trimmed := bytes.TrimSpace(b); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &raws); err != nil {
+			return nil, err
+		}
+	} else {
+		raws = []json.RawMessage{b}
+	}
+
+	for _, raw := range raws {
+		var partialResultSet sppb.PartialResultSet
+		if err := protojson.Unmarshal(raw, &partialResultSet); err != nil {
+			return nil, err
+		}
+		if metadata := partialResultSet.GetMetadata(); metadata != nil {
+			return &input{kind: inputPartialResultSet, fields: metadata.GetRowType().GetFields()}, nil
+		}
+	}
+	return nil, fmt.Errorf("no PartialResultSet has metadata")
+}
+
+// decodeParams decodes an ExecuteSqlRequest or a bare param_types map.
+func decodeParams(b []byte) (*input, error) {
+	var req sppb.ExecuteSqlRequest
+	if err := protojson.Unmarshal(b, &req); err == nil {
+		return &input{kind: inputParams, params: req.GetParamTypes()}, nil
+	}
+
+	var raws map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raws); err != nil {
+		return nil, err
+	}
+	params := make(map[string]*sppb.Type, len(raws))
+	for name, raw := range raws {
+		var typ sppb.Type
+		if err := protojson.Unmarshal(raw, &typ); err != nil {
+			return nil, fmt.Errorf("param %v: %w", name, err)
+		}
+		params[name] = &typ
+	}
+	return &input{kind: inputParams, params: params}, nil
+}
+
+// printInput prints the decoded input using FormatOption, or Go syntax if goOpts is not nil.
+func printInput(w io.Writer, in *input, formatOpt spantype.FormatOption, goOpts *spantype.GoSyntaxOption) error {
+	switch {
+	case in.kind == inputType && goOpts != nil:
+		_, err := fmt.Fprintln(w, spantype.FormatGoSyntax(in.typ, *goOpts))
+		return err
+	case in.kind == inputType:
+		str, err := spantype.TryFormatType(in.typ, formatOpt)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, str)
+		return err
+	case in.kind == inputParams:
+		return printParams(w, in.params, formatOpt, goOpts)
+	case goOpts != nil:
+		_, err := fmt.Fprintln(w, spantype.FormatStructFieldsGoSyntax(in.fields, *goOpts))
+		return err
+	default:
+		str, err := spantype.TryFormatStructFields(in.fields, formatOpt)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, str)
+		return err
+	}
+}
+
+// printParams prints param types sorted by name as `@name TYPE` lines, or a Go map literal.
+func printParams(w io.Writer, params map[string]*sppb.Type, formatOpt spantype.FormatOption, goOpts *spantype.GoSyntaxOption) error {
+	var sb strings.Builder
+	if goOpts != nil {
+		sppbName := goOpts.SpannerpbName
+		switch sppbName {
+		case "":
+			sppbName = "sppb."
+		case ".":
+			sppbName = ""
+		default:
+			sppbName += "."
+		}
+		sb.WriteString("map[string]*" + sppbName + "Type{\n")
+		opts := *goOpts
+		opts.Multiline = false
+		for _, name := range sortedKeys(params) {
+			fmt.Fprintf(&sb, "\t%v: %v,\n", strconv.Quote(name), spantype.FormatGoSyntax(params[name], opts))
+		}
+		sb.WriteString("}\n")
+	} else {
+		for _, name := range sortedKeys(params) {
+			line, err := spantype.TryFormatParams(nil, map[string]*sppb.Type{name: params[name]}, formatOpt)
+			if err != nil {
+				return err
+			}
+			sb.WriteString(line + "\n")
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// formatInputLine formats the decoded input in a single line. Params are separated by `, `.
+func formatInputLine(in *input, formatOpt spantype.FormatOption) (string, error) {
+	switch {
+	case in.kind == inputType:
+		return spantype.TryFormatType(in.typ, formatOpt)
+	case in.kind == inputParams:
+		return spantype.TryFormatParams(nil, in.params, formatOpt)
+	default:
+		return spantype.TryFormatStructFields(in.fields, formatOpt)
+	}
+}
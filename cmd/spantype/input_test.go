@@ -0,0 +1,177 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+
+	. "github.com/apstndb/spantype/typector"
+)
+
+func mustMarshal(t *testing.T, m proto.Message) []byte {
+	t.Helper()
+	b, err := proto.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestDetectInputKind(t *testing.T) {
+	tests := []struct {
+		desc    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"type", `{"code": "INT64"}`, inputType, false},
+		{"struct type", `{"fields": [{"name": "n", "type": {"code": "INT64"}}]}`, inputStructType, false},
+		{"metadata", `{"rowType": {"fields": []}}`, inputMetadata, false},
+		{"metadata with snake case", `{"row_type": {"fields": []}}`, inputMetadata, false},
+		{"undeclared parameters", `{"undeclaredParameters": {"fields": []}}`, inputMetadata, false},
+		{"result set", `{"metadata": {"rowType": {"fields": []}}, "rows": []}`, inputResultSet, false},
+		{"partial result set", `{"metadata": {"rowType": {"fields": []}}, "values": []}`, inputPartialResultSet, false},
+		{"partial result sets", `[{"values": []}]`, inputPartialResultSet, false},
+		{"execute sql request", `{"sql": "SELECT @n", "paramTypes": {"n": {"code": "INT64"}}}`, inputParams, false},
+		{"bare param types", `{"n": {"code": "INT64"}, "s": {"code": "STRING"}}`, inputParams, false},
+		// A param named `code` is ambiguous with Type, and Type wins.
+		{"param named code", `{"code": {"code": "INT64"}}`, inputType, false},
+		{"param named fields", `{"fields": {"code": "INT64"}}`, inputStructType, false},
+		{"empty object", `{}`, "", true},
+		{"object of non-types", `{"n": 1}`, "", true},
+		{"object of objects without code", `{"n": {"name": "x"}}`, "", true},
+		{"not JSON object", `"INT64"`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := detectInputKind([]byte(tt.input))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("detectInputKind should fail, but got: %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("detectInputKind failed: %v", err)
+			}
+			if tt.want != got {
+				t.Errorf("detectInputKind want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDetectInputFormat(t *testing.T) {
+	tests := []struct {
+		desc  string
+		input []byte
+		want  string
+	}{
+		{"JSON object", []byte(`{"code": "INT64"}`), inputFormatJSON},
+		{"JSON array", []byte(" \n[{}]"), inputFormatJSON},
+		{"empty", nil, inputFormatJSON},
+		{"text", []byte("code: INT64\n"), inputFormatText},
+		{"text wrapped in braces", []byte(`{code: INT64}`), inputFormatJSON},
+		{"binary", mustMarshal(t, Int64()), inputFormatBinary},
+		{"invalid UTF-8", []byte("code: \xff"), inputFormatBinary},
+		// Binary input whose bytes are all printable or whitespace can't be distinguished from text.
+		{"printable binary", mustMarshal(t, &sppb.StructType{Fields: []*sppb.StructType_Field{{Name: "abcdefghijklmnopqrstuvwxyzABCDEFGHIJ"}}}), inputFormatText},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := detectInputFormat(tt.input); tt.want != got {
+				t.Errorf("detectInputFormat want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDecodeInput(t *testing.T) {
+	printable := &sppb.StructType{Fields: []*sppb.StructType_Field{{Name: "abcdefghijklmnopqrstuvwxyzABCDEFGHIJ"}}}
+	metadata := &sppb.ResultSetMetadata{RowType: &sppb.StructType{Fields: []*sppb.StructType_Field{
+		NameTypeToStructTypeField("n", Int64()),
+		NameTypeToStructTypeField("s", ElemCodeToArrayType(sppb.TypeCode_STRING)),
+	}}}
+
+	tests := []struct {
+		desc    string
+		input   []byte
+		kind    string
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{desc: "JSON type", input: []byte(`{"code": "INT64"}`), kind: inputAuto, format: inputFormatAuto, want: "INT64"},
+		{desc: "JSON metadata", input: []byte(`{"rowType": {"fields": [{"name": "n", "type": {"code": "INT64"}}]}}`), kind: inputAuto, format: inputFormatAuto, want: "n INT64"},
+		{desc: "JSON params", input: []byte(`{"sql": "SELECT 1", "paramTypes": {"b": {"code": "BOOL"}, "a": {"code": "INT64"}}}`), kind: inputAuto, format: inputFormatAuto, want: "@a INT64, @b BOOL"},
+		{desc: "JSON partial result sets", input: []byte(`[{"values": []}, {"metadata": {"rowType": {"fields": [{"name": "n", "type": {"code": "INT64"}}]}}}]`), kind: inputAuto, format: inputFormatAuto, want: "n INT64"},
+		{desc: "JSON with explicit kind", input: []byte(`{"fields": [{"name": "n", "type": {"code": "INT64"}}]}`), kind: inputStructType, format: inputFormatAuto, want: "n INT64"},
+		{desc: "JSON of wrong kind", input: []byte(`{"code": "INT64"}`), kind: inputMetadata, format: inputFormatAuto, wantErr: true},
+		{desc: "empty JSON object", input: []byte(`{}`), kind: inputAuto, format: inputFormatAuto, wantErr: true},
+		{desc: "text type", input: []byte("code: INT64"), kind: inputAuto, format: inputFormatAuto, want: "INT64"},
+		{desc: "text metadata", input: []byte(prototext.Format(metadata)), kind: inputAuto, format: inputFormatAuto, want: "n INT64, s ARRAY<STRING>"},
+		{desc: "text wrapped in braces", input: []byte(`{code: INT64}`), kind: inputAuto, format: inputFormatAuto, want: "INT64"},
+		{desc: "text wrapped in braces with explicit format", input: []byte(`{row_type: {fields: {name: "n" type: {code: INT64}}}}`), kind: inputMetadata, format: inputFormatText, want: "n INT64"},
+		{desc: "empty braces", input: []byte(`{ }`), kind: inputAuto, format: inputFormatText, wantErr: true},
+		{desc: "binary", input: mustMarshal(t, metadata), kind: inputMetadata, format: inputFormatAuto, want: "n INT64, s ARRAY<STRING>"},
+		{desc: "binary without kind", input: mustMarshal(t, metadata), kind: inputAuto, format: inputFormatAuto, wantErr: true},
+		{desc: "printable binary", input: mustMarshal(t, printable), kind: inputStructType, format: inputFormatAuto, want: "abcdefghijklmnopqrstuvwxyzABCDEFGHIJ TYPE_CODE_UNSPECIFIED"},
+		{desc: "printable binary without kind", input: mustMarshal(t, printable), kind: inputAuto, format: inputFormatAuto, wantErr: true},
+		{desc: "printable binary with explicit text format", input: mustMarshal(t, printable), kind: inputStructType, format: inputFormatText, wantErr: true},
+		{desc: "unknown format", input: []byte(`{"code": "INT64"}`), kind: inputAuto, format: "yaml", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			in, err := decodeInput(tt.input, tt.kind, tt.format)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("decodeInput should fail, but got: %+v", in)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeInput failed: %v", err)
+			}
+			got, err := formatInputLine(in, spantype.FormatOptionVerbose)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want != got {
+				t.Errorf("decodeInput want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPrintInput(t *testing.T) {
+	tests := []struct {
+		desc  string
+		input string
+		goOpt *spantype.GoSyntaxOption
+		want  string
+	}{
+		{desc: "request without param_types", input: `{"sql": "SELECT 1"}`, want: ""},
+		{desc: "request without param_types in Go syntax", input: `{"sql": "SELECT 1"}`, goOpt: &spantype.GoSyntaxOption{}, want: "map[string]*sppb.Type{\n}\n"},
+		{desc: "empty row type", input: `{"rowType": {}}`, want: "\n"},
+		{desc: "empty row type in Go syntax", input: `{"rowType": {}}`, goOpt: &spantype.GoSyntaxOption{}, want: "[]*sppb.StructType_Field{}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			in, err := decodeInput([]byte(tt.input), inputAuto, inputFormatAuto)
+			if err != nil {
+				t.Fatalf("decodeInput failed: %v", err)
+			}
+			var sb strings.Builder
+			if err := printInput(&sb, in, spantype.FormatOptionVerbose, tt.goOpt); err != nil {
+				t.Fatalf("printInput failed: %v", err)
+			}
+			if got := sb.String(); tt.want != got {
+				t.Errorf("printInput want: %q, got: %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// stringsFlag is a repeatable string flag.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string { return strings.Join(*f, ", ") }
+
+func (f *stringsFlag) Set(s string) error {
+	*f = append(*f, s)
+	return nil
+}
+
+func dialectFromString(s string) (spantype.Dialect, error) {
+	switch strings.ToLower(s) {
+	case "googlesql":
+		return spantype.DialectGoogleSQL, nil
+	case "postgresql", "pg":
+		return spantype.DialectPostgreSQL, nil
+	default:
+		return 0, fmt.Errorf("unknown dialect: %v", s)
+	}
+}
+
+func runLint(args []string) error {
+	fs := newFlagSet("lint", "FILE", "lint checks column types in FILE, which is a DDL file or a row type JSON file, and exits with 1 if there are findings.")
+	dialectStr := fs.String("dialect", "googlesql", "DDL dialect (googlesql|postgresql)")
+	descriptors := fs.String("descriptors", "", "FileDescriptorSet file; PROTO and ENUM types must be registered in it")
+	forbidMaxKey := fs.Bool("forbid-max-key", false, "forbid STRING(MAX) and BYTES(MAX) in primary keys")
+	var forbids stringsFlag
+	fs.Var(&forbids, "forbid", "forbidden type as `TYPE[:TABLE,...]`, e.g. ARRAY<JSON> or FLOAT32:Prices (repeatable)")
+	if err := parseFlags(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	dialect, err := dialectFromString(*dialectStr)
+	if err != nil {
+		return usageErrorf(fs, "%v", err)
+	}
+
+	var rules []spantype.Rule
+	if *forbidMaxKey {
+		rules = append(rules, spantype.ForbidMaxLengthInKey())
+	}
+	for _, forbid := range forbids {
+		typeStr, tablesStr, _ := strings.Cut(forbid, ":")
+		typ, err := spantype.ParseType(typeStr, spantype.ParseOption{Dialect: dialect})
+		if err != nil {
+			return usageErrorf(fs, "invalid -forbid %q: %v", forbid, err)
+		}
+		var tables []string
+		if tablesStr != "" {
+			tables = strings.Split(tablesStr, ",")
+		}
+		rules = append(rules, spantype.ForbidType(typ, tables...))
+	}
+	parseOpt := spantype.ParseOption{Dialect: dialect}
+	if *descriptors != "" {
+		files, err := readFileDescriptorSet(*descriptors)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, spantype.RequireRegisteredProto(files))
+		parseOpt.Resolver = files
+	}
+
+	b, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var tables []*spantype.Table
+	if trimmed := bytes.TrimSpace(b); len(trimmed) > 0 && trimmed[0] == '{' {
+		var rowType sppb.StructType
+		if err := protojson.Unmarshal(b, &rowType); err != nil {
+			return err
+		}
+		tables = []*spantype.Table{{RowType: &rowType}}
+	} else {
+		if tables, err = spantype.ParseDDL(string(b), parseOpt); err != nil {
+			return err
+		}
+	}
+
+	findings := spantype.Lint(tables, rules)
+	for _, finding := range findings {
+		fmt.Println(finding)
+	}
+	if len(findings) > 0 {
+		return errFindings
+	}
+	return nil
+}
+
+func readFileDescriptorSet(name string) (*protoregistry.Files, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var fds descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(b, &fds); err != nil {
+		return nil, err
+	}
+	return protodesc.NewFiles(&fds)
+}
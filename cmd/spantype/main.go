@@ -1,57 +1,161 @@
 package main
 
 import (
-	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"strings"
 
-	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
 	"github.com/apstndb/spantype"
-	"google.golang.org/protobuf/encoding/protojson"
 )
 
+var (
+	// errUsage is returned for invalid command line arguments. It exits with 2.
+	errUsage = errors.New("usage error")
+	// errFindings is returned when lint rules are violated, types differ, or types are invalid.
+	// The details are already printed, so it exits with 1 without a message.
+	errFindings = errors.New("findings")
+)
+
+const usage = `Usage: spantype <command> [flags] [args]
+
+Commands:
+  format    format types in protobuf messages (default)
+  parse     parse a type string and print it as protobuf JSON
+  diff      compare types in two protobuf message files
+  validate  validate types in protobuf messages
+  convert   convert a row type to JSON Schema, TypeScript, Go, Avro, or DDL
+  table     render rows of a result set as a table
+  batch     format types in NDJSON logs line by line
+  repl      explore types interactively
+  lint      check column types in DDL or a row type
+
+Run "spantype <command> -h" for the flags of each command.
+`
+
+var commands = map[string]func(args []string) error{
+	"format":   runFormat,
+	"parse":    runParse,
+	"diff":     runDiff,
+	"validate": runValidate,
+	"convert":  runConvert,
+	"table":    runTable,
+	"batch":    runBatch,
+	"repl":     runREPL,
+	"lint":     runLint,
+}
+
 func main() {
-	if err := run(context.Background()); err != nil {
-		log.Fatalln(err)
-	}
-}
-
-func modeToFormatOption(mode string) spantype.FormatOption {
-	switch strings.ToLower(mode) {
-	case "more":
-		return spantype.FormatOptionMoreVerbose
-	case "verbose":
-		return spantype.FormatOptionVerbose
-	case "normal":
-		return spantype.FormatOptionNormal
-	case "simplest":
-		return spantype.FormatOptionSimplest
-	case "simple":
-		return spantype.FormatOptionSimple
+	os.Exit(run(os.Args[1:]))
+}
+
+// run runs the command and returns the exit code.
+func run(args []string) int {
+	// Without a command, it is `format` for compatibility.
+	cmd, cmdArgs := "format", args
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, cmdArgs = args[0], args[1:]
+	}
+
+	var err error
+	switch f, ok := commands[cmd]; {
+	case cmd == "help":
+		fmt.Fprint(os.Stdout, usage)
+		return 0
+	case !ok:
+		fmt.Fprintf(os.Stderr, "spantype: unknown command %q\n\n%v", cmd, usage)
+		return 2
 	default:
-		panic("unknown mode: " + mode)
+		err = f(cmdArgs)
+	}
+
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	case errors.Is(err, errFindings):
+		return 1
+	default:
+		fmt.Fprintf(os.Stderr, "spantype %v: %v\n", cmd, err)
+		return 1
 	}
 }
 
-func run(ctx context.Context) error {
-	mode := flag.String("mode", "verbose", "format mode (simplest|simple|normal|verbose|more)")
-	flag.Parse()
+// newFlagSet returns a FlagSet which returns errors instead of exit.
+// argsUsage and description are printed in the usage.
+func newFlagSet(name, argsUsage, description string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: spantype %v [flags] %v\n\n%v\n\n", name, argsUsage, description)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses args and checks the number of positional arguments is in [minArgs, maxArgs].
+func parseFlags(fs *flag.FlagSet, args []string, minArgs, maxArgs int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+	if fs.NArg() < minArgs || fs.NArg() > maxArgs {
+		return usageErrorf(fs, "wrong number of arguments: %d", fs.NArg())
+	}
+	return nil
+}
+
+// usageErrorf prints the message and the usage of fs, and returns errUsage.
+func usageErrorf(fs *flag.FlagSet, format string, args ...any) error {
+	msg := fmt.Sprintf(format, args...)
+	fmt.Fprintf(fs.Output(), "%v\n", msg)
+	fs.Usage()
+	return fmt.Errorf("%w: %v", errUsage, msg)
+}
 
-	formatOpt := modeToFormatOption(*mode)
+// readFileOrStdin reads the named file, or stdin if name is empty or `-`.
+func readFileOrStdin(name string) ([]byte, error) {
+	if name == "" || name == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(name)
+}
 
-	b, err := io.ReadAll(os.Stdin)
-	if err != nil {
-		return err
+// formatOptionFlags are flags of FormatOption axes, which override the preset of -mode.
+type formatOptionFlags struct {
+	keys   []string
+	values []*string
+}
+
+func addFormatOptionFlags(fs *flag.FlagSet) *formatOptionFlags {
+	f := &formatOptionFlags{}
+	for _, axis := range []struct{ key, usage string }{
+		{"struct", "STRUCT format (base|recursive|named)"},
+		{"proto", "PROTO format (base|leaf|full|leaf-kind|full-kind|relative|relative-kind)"},
+		{"enum", "ENUM format (base|leaf|full|leaf-kind|full-kind|relative|relative-kind)"},
+		{"array", "ARRAY format (base|recursive)"},
+		{"unknown", "unknown type code format (unknown|code|verbose|panic)"},
+		{"annotation", "type annotation format (base|name)"},
+	} {
+		f.keys = append(f.keys, axis.key)
+		f.values = append(f.values, fs.String(axis.key, "", axis.usage+", overriding -mode"))
 	}
+	return f
+}
 
-	var structType sppb.StructType
-	if err := protojson.Unmarshal(b, &structType); err != nil {
-		return err
+// formatOption returns the FormatOption of the mode overridden by the flags.
+func (f *formatOptionFlags) formatOption(mode string) (spantype.FormatOption, error) {
+	spec := []string{mode}
+	for i, key := range f.keys {
+		if *f.values[i] != "" {
+			spec = append(spec, key+"="+*f.values[i])
+		}
 	}
-	fmt.Println(spantype.FormatStructFields(structType.GetFields(), formatOpt))
-	return nil
+	return spantype.ParseFormatOption(strings.Join(spec, ","))
 }
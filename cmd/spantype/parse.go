@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/apstndb/spantype"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func runParse(args []string) error {
+	fs := newFlagSet("parse", "TYPE", "parse parses a type string, e.g. 'ARRAY<STRUCT<n INT64>>', and prints it as protobuf JSON.")
+	dialectStr := fs.String("dialect", "googlesql", "type dialect (googlesql|postgresql)")
+	if err := parseFlags(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	dialect, err := dialectFromString(*dialectStr)
+	if err != nil {
+		return usageErrorf(fs, "%v", err)
+	}
+
+	typ, err := spantype.ParseType(fs.Arg(0), spantype.ParseOption{Dialect: dialect})
+	if err != nil {
+		return err
+	}
+
+	b, err := protojson.Marshal(typ)
+	if err != nil {
+		return err
+	}
+	// protojson randomizes whitespaces, so indent it for stable output.
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, b, "", "  "); err != nil {
+		return err
+	}
+	fmt.Println(buf.String())
+	return nil
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const replHelp = `Enter a type string, e.g. ARRAY<STRUCT<n INT64>>, or protobuf JSON of a type, a row type, or metadata.
+JSON can span multiple lines.
+
+Commands:
+  :dialect [googlesql|postgresql]  show or set the dialect of type strings
+  :option [SPEC|reset]             show or set a custom FormatOption, e.g. :option verbose,proto=full-kind
+  :help                            show this help
+  :quit                            exit
+`
+
+func runREPL(args []string) error {
+	fs := newFlagSet("repl", "", "repl reads types interactively, and shows them in all presets, protobuf JSON, Go syntax, and column types of both dialects.")
+	dialectStr := fs.String("dialect", "googlesql", "dialect of type strings (googlesql|postgresql)")
+	if err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	dialect, err := dialectFromString(*dialectStr)
+	if err != nil {
+		return usageErrorf(fs, "%v", err)
+	}
+
+	// Prompts are only useful for terminals.
+	var prompt bool
+	if fi, err := os.Stdin.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+		prompt = true
+	}
+
+	r := &repl{w: os.Stdout, dialect: dialect, prompt: prompt}
+	return r.run(os.Stdin)
+}
+
+type repl struct {
+	w       io.Writer
+	dialect spantype.Dialect
+	prompt  bool
+	// custom is shown in addition to the presets if it is not nil.
+	custom *spantype.FormatOption
+}
+
+func (r *repl) run(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	// pending is an incomplete JSON input.
+	var pending []byte
+	r.printPrompt(pending)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case len(pending) > 0:
+			pending = append(append(pending, '\n'), line...)
+			if json.Valid(pending) {
+				r.showJSON(pending)
+				pending = nil
+			}
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, ":"):
+			if quit := r.command(trimmed); quit {
+				return nil
+			}
+		case strings.HasPrefix(trimmed, "{"):
+			if json.Valid([]byte(trimmed)) {
+				r.showJSON([]byte(trimmed))
+			} else {
+				pending = []byte(line)
+			}
+		default:
+			typ, err := spantype.ParseType(trimmed, spantype.ParseOption{Dialect: r.dialect})
+			if err != nil {
+				r.printf("error: %v\n", err)
+			} else {
+				r.show(typ)
+			}
+		}
+		r.printPrompt(pending)
+	}
+	if len(pending) > 0 {
+		r.printf("error: incomplete JSON\n")
+	}
+	return scanner.Err()
+}
+
+func (r *repl) printf(format string, args ...any) {
+	fmt.Fprintf(r.w, format, args...)
+}
+
+func (r *repl) printPrompt(pending []byte) {
+	switch {
+	case !r.prompt:
+	case len(pending) > 0:
+		r.printf("... ")
+	default:
+		r.printf("spantype> ")
+	}
+}
+
+// command runs the REPL command, and reports whether the REPL should quit.
+func (r *repl) command(line string) bool {
+	cmd, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	switch cmd {
+	case ":quit", ":q", ":exit":
+		return true
+	case ":help", ":h":
+		r.printf("%v", replHelp)
+	case ":dialect":
+		if arg != "" {
+			dialect, err := dialectFromString(arg)
+			if err != nil {
+				r.printf("error: %v\n", err)
+				return false
+			}
+			r.dialect = dialect
+		}
+		r.printf("dialect: %v\n", dialectName(r.dialect))
+	case ":option":
+		switch arg {
+		case "":
+		case "reset":
+			r.custom = nil
+		default:
+			opts, err := spantype.ParseFormatOption(arg)
+			if err != nil {
+				r.printf("error: %v\n", err)
+				return false
+			}
+			r.custom = &opts
+		}
+		if r.custom == nil {
+			r.printf("option: none\n")
+		} else {
+			r.printf("option: %v\n", r.custom)
+		}
+	default:
+		r.printf("error: unknown command %v; see :help\n", cmd)
+	}
+	return false
+}
+
+// showJSON shows the type in protobuf JSON. Row types and params are shown as STRUCT.
+func (r *repl) showJSON(b []byte) {
+	in, err := decodeInput(b, inputAuto, inputFormatJSON)
+	if err != nil {
+		r.printf("error: %v\n", err)
+		return
+	}
+
+	switch {
+	case in.kind == inputType:
+		r.show(in.typ)
+	case in.kind == inputParams:
+		var fields []*sppb.StructType_Field
+		for _, name := range sortedKeys(in.params) {
+			fields = append(fields, &sppb.StructType_Field{Name: name, Type: in.params[name]})
+		}
+		r.printf("(params as STRUCT)\n")
+		r.show(&sppb.Type{Code: sppb.TypeCode_STRUCT, StructType: &sppb.StructType{Fields: fields}})
+	default:
+		r.printf("(row type as STRUCT)\n")
+		r.show(&sppb.Type{Code: sppb.TypeCode_STRUCT, StructType: &sppb.StructType{Fields: in.fields}})
+	}
+}
+
+// show prints the type in all presets, the custom option, protobuf JSON, Go syntax, and column types.
+func (r *repl) show(typ *sppb.Type) {
+	tw := tabwriter.NewWriter(r.w, 0, 8, 2, ' ', 0)
+	row := func(name, value string) {
+		fmt.Fprintf(tw, "%v\t%v\n", name, value)
+	}
+	formatted := func(opts spantype.FormatOption) string {
+		s, err := spantype.TryFormatType(typ, opts)
+		if err != nil {
+			return "error: " + err.Error()
+		}
+		return s
+	}
+
+	for _, name := range []string{"simplest", "simple", "normal", "verbose", "more"} {
+		opts, _ := spantype.LookupFormatOption(name)
+		row(name, formatted(opts))
+	}
+	if r.custom != nil {
+		row("option", formatted(*r.custom))
+	}
+
+	if b, err := protojson.Marshal(typ); err != nil {
+		row("protojson", "error: "+err.Error())
+	} else {
+		// protojson randomizes whitespaces, so compact it for stable output.
+		var buf bytes.Buffer
+		json.Compact(&buf, b)
+		row("protojson", buf.String())
+	}
+	row("go", spantype.FormatGoSyntax(typ, spantype.GoSyntaxOption{}))
+
+	for _, dialect := range []spantype.Dialect{spantype.DialectGoogleSQL, spantype.DialectPostgreSQL} {
+		if s, err := spantype.FormatColumnType(typ, dialect); err != nil {
+			row(dialectName(dialect), "(not a column type: "+err.Error()+")")
+		} else {
+			row(dialectName(dialect), s)
+		}
+	}
+	tw.Flush()
+	r.printf("\n")
+}
+
+func dialectName(dialect spantype.Dialect) string {
+	if dialect == spantype.DialectPostgreSQL {
+		return "postgresql"
+	}
+	return "googlesql"
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/apstndb/spantype"
+)
+
+func TestREPL(t *testing.T) {
+	tests := []struct {
+		golden string
+		prompt bool
+		input  string
+	}{
+		{"repl/session.golden", false, `:help
+INT64
+:dialect
+:dialect postgresql
+character varying[]
+numeric
+:dialect mysql
+:dialect googlesql
+ARRAY<INT64
+:option verbose,proto=full-kind
+STRUCT<b PROTO<examples.Book>>
+:option bogus=1
+:option
+:option reset
+:option
+{"code": "ARRAY",
+ "arrayElementType": {"code": "STRING"}}
+{"fields": [{"name": "n", "type": {"code": "INT64"}}, {"type": {"code": "BOOL"}}]}
+{"sql": "SELECT @b, @a", "paramTypes": {"b": {"code": "BOOL"}, "a": {"code": "INT64"}}}
+{"unknown": 1}
+:unknown
+`},
+		{"repl/prompt.golden", true, `INT64
+{"code":
+  "STRING"}
+:quit
+BOOL
+`},
+		{"repl/incomplete.golden", false, `{"code": "ARRAY",
+  "arrayElementType":
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			var buf bytes.Buffer
+			r := &repl{w: &buf, dialect: spantype.DialectGoogleSQL, prompt: tt.prompt}
+			if err := r.run(strings.NewReader(tt.input)); err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+			checkGolden(t, tt.golden, buf.String())
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func runTable(args []string) error {
+	fs := newFlagSet("table", "[FILE]", "table renders rows of ResultSet JSON read from FILE or stdin as a table with `name TYPE` headers.")
+	mode := fs.String("mode", "verbose", "format mode of types and values (simplest|simple|normal|verbose|more), or a spantype.ParseFormatOption specification")
+	vertical := fs.Bool("vertical", false, "print each row vertically")
+	maxWidth := fs.Int("max-width", 0, "truncate headers and values longer than this width, or 0 for no limit")
+	nullStr := fs.String("null", "NULL", "string for NULL values")
+	descriptors := fs.String("descriptors", "", "FileDescriptorSet file to decode PROTO and ENUM values")
+	optionFlags := addFormatOptionFlags(fs)
+	if err := parseFlags(fs, args, 0, 1); err != nil {
+		return err
+	}
+
+	formatOpt, err := optionFlags.formatOption(*mode)
+	if err != nil {
+		return usageErrorf(fs, "%v", err)
+	}
+	if *maxWidth < 0 {
+		return usageErrorf(fs, "-max-width must not be negative: %v", *maxWidth)
+	}
+	if *descriptors != "" {
+		files, err := readFileDescriptorSet(*descriptors)
+		if err != nil {
+			return err
+		}
+		formatOpt.Resolver = files
+	}
+
+	b, err := readFileOrStdin(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var resultSet sppb.ResultSet
+	if err := protojson.Unmarshal(b, &resultSet); err != nil {
+		return err
+	}
+	return renderTable(os.Stdout, &resultSet, tableOption{format: formatOpt, vertical: *vertical, maxWidth: *maxWidth, null: *nullStr})
+}
+
+// tableOption is the option of renderTable.
+type tableOption struct {
+	format   spantype.FormatOption
+	vertical bool
+	// maxWidth truncates headers and values longer than it, or 0 for no limit.
+	maxWidth int
+	// null is the string for NULL column values.
+	null string
+}
+
+// renderTable writes the rows of the result set as a table, or vertically.
+func renderTable(w io.Writer, resultSet *sppb.ResultSet, opts tableOption) error {
+	fields := resultSet.GetMetadata().GetRowType().GetFields()
+	header := make([]string, len(fields))
+	for i, field := range fields {
+		str, err := spantype.TryFormatType(field.GetType(), opts.format)
+		if err != nil {
+			return fmt.Errorf("column %d: %w", i, err)
+		}
+		header[i] = strings.TrimSpace(field.GetName() + " " + str)
+	}
+
+	rows := make([][]string, 0, len(resultSet.GetRows()))
+	for i, row := range resultSet.GetRows() {
+		values := row.GetValues()
+		if len(values) != len(fields) {
+			return fmt.Errorf("row %d has %d values, but there are %d columns", i, len(values), len(fields))
+		}
+		cells := make([]string, len(values))
+		for j, value := range values {
+			cells[j] = formatCell(fields[j].GetType(), value, opts.format, opts.null)
+		}
+		rows = append(rows, cells)
+	}
+
+	if opts.maxWidth > 0 {
+		truncateAll(header, opts.maxWidth)
+		for _, cells := range rows {
+			truncateAll(cells, opts.maxWidth)
+		}
+	}
+
+	if opts.vertical {
+		return writeVertical(w, header, rows)
+	}
+	return writeTable(w, header, rows)
+}
+
+// formatCell formats the column value. Only the NULL column value is replaced by nullStr, not NULLs in ARRAY or STRUCT.
+func formatCell(typ *sppb.Type, value *structpb.Value, opts spantype.FormatOption, nullStr string) string {
+	if _, ok := value.GetKind().(*structpb.Value_NullValue); ok {
+		return nullStr
+	}
+	// Newlines would break the table.
+	return strings.ReplaceAll(spantype.FormatValue(typ, value, opts), "\n", " ")
+}
+
+func truncateAll(ss []string, width int) {
+	for i, s := range ss {
+		if utf8.RuneCountInString(s) > width {
+			ss[i] = string([]rune(s)[:max(width-1, 0)]) + "…"
+		}
+	}
+}
+
+// writeTable writes rows as a table with borders.
+func writeTable(w io.Writer, header []string, rows [][]string) error {
+	widths := make([]int, len(header))
+	for _, cells := range append([][]string{header}, rows...) {
+		for i, cell := range cells {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	var sb strings.Builder
+	writeBorder := func() {
+		sb.WriteByte('+')
+		for _, width := range widths {
+			sb.WriteString(strings.Repeat("-", width+2) + "+")
+		}
+		sb.WriteByte('\n')
+	}
+	writeRow := func(cells []string) {
+		sb.WriteByte('|')
+		for i, cell := range cells {
+			sb.WriteString(" " + cell + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)) + " |")
+		}
+		sb.WriteByte('\n')
+	}
+
+	writeBorder()
+	writeRow(header)
+	writeBorder()
+	for _, cells := range rows {
+		writeRow(cells)
+	}
+	if len(rows) > 0 {
+		writeBorder()
+	}
+	sb.WriteString(rowsInSet(len(rows)))
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeVertical writes each row as `header: value` lines with right-aligned headers.
+func writeVertical(w io.Writer, header []string, rows [][]string) error {
+	var width int
+	for _, h := range header {
+		width = max(width, utf8.RuneCountInString(h))
+	}
+
+	var sb strings.Builder
+	for i, cells := range rows {
+		fmt.Fprintf(&sb, "*************************** %d. row ***************************\n", i+1)
+		for j, cell := range cells {
+			sb.WriteString(strings.Repeat(" ", width-utf8.RuneCountInString(header[j])) + header[j] + ": " + cell + "\n")
+		}
+	}
+	sb.WriteString(rowsInSet(len(rows)))
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func rowsInSet(n int) string {
+	if n == 1 {
+		return "1 row in set\n"
+	}
+	return fmt.Sprintf("%d rows in set\n", n)
+}
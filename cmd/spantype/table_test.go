@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const tableResultSet = `{
+  "metadata": {"rowType": {"fields": [
+    {"name": "n", "type": {"code": "INT64"}},
+    {"name": "s", "type": {"code": "STRING"}},
+    {"name": "tags", "type": {"code": "ARRAY", "arrayElementType": {"code": "STRING"}}},
+    {"name": "st", "type": {"code": "ARRAY", "arrayElementType": {"code": "STRUCT", "structType": {"fields": [{"name": "x", "type": {"code": "FLOAT64"}}]}}}},
+    {"type": {"code": "BOOL"}}
+  ]}},
+  "rows": [
+    ["1", "hello", ["a", null], [[1.5]], true],
+    [null, null, null, [], null],
+    ["-9223372036854775808", "multi\nline ünïcödé text", ["long long long value"], [["NaN"]], false]
+  ]
+}`
+
+func TestRenderTable(t *testing.T) {
+	var resultSet sppb.ResultSet
+	if err := protojson.Unmarshal([]byte(tableResultSet), &resultSet); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		golden    string
+		resultSet *sppb.ResultSet
+		opts      tableOption
+	}{
+		{"table/table.golden", &resultSet, tableOption{format: spantype.FormatOptionVerbose, null: "NULL"}},
+		{"table/vertical.golden", &resultSet, tableOption{format: spantype.FormatOptionVerbose, vertical: true, null: "NULL"}},
+		{"table/max-width.golden", &resultSet, tableOption{format: spantype.FormatOptionVerbose, maxWidth: 8, null: "NULL"}},
+		{"table/vertical-max-width.golden", &resultSet, tableOption{format: spantype.FormatOptionVerbose, vertical: true, maxWidth: 8, null: "NULL"}},
+		{"table/null.golden", &resultSet, tableOption{format: spantype.FormatOptionSimplest, null: "<null>"}},
+		{"table/empty.golden", &sppb.ResultSet{Metadata: resultSet.GetMetadata()}, tableOption{format: spantype.FormatOptionVerbose, null: "NULL"}},
+		{"table/empty-vertical.golden", &sppb.ResultSet{Metadata: resultSet.GetMetadata()}, tableOption{format: spantype.FormatOptionVerbose, vertical: true, null: "NULL"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := renderTable(&buf, tt.resultSet, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.golden, buf.String())
+		})
+	}
+}
+
+func TestRenderTableError(t *testing.T) {
+	tests := []struct {
+		desc      string
+		resultSet string
+		opts      tableOption
+	}{
+		{"row length mismatch", `{"metadata": {"rowType": {"fields": [{"name": "n", "type": {"code": "INT64"}}]}}, "rows": [["1", "2"]]}`,
+			tableOption{format: spantype.FormatOptionVerbose}},
+		{"unknown type code with panic mode", `{"metadata": {"rowType": {"fields": [{"name": "n", "type": {"code": 100}}]}}}`,
+			tableOption{format: spantype.FormatOption{Unknown: spantype.UnknownModePanic}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var resultSet sppb.ResultSet
+			if err := protojson.Unmarshal([]byte(tt.resultSet), &resultSet); err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := renderTable(&buf, &resultSet, tt.opts); err == nil {
+				t.Errorf("renderTable should fail, but got:\n%v", buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/apstndb/spantype"
+)
+
+func runValidate(args []string) error {
+	fs := newFlagSet("validate", "[FILE]", "validate reports structural problems of types in protobuf JSON, binary, or text format read from FILE or stdin, and exits with 1 if there are any.")
+	inputs := addInputFlags(fs)
+	disallowDuplicates := fs.Bool("disallow-duplicate-field-names", false, "report STRUCT fields with the same name")
+	if err := parseFlags(fs, args, 0, 1); err != nil {
+		return err
+	}
+
+	b, err := readFileOrStdin(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	in, err := inputs.decode(b)
+	if err != nil {
+		return err
+	}
+
+	opts := spantype.ValidateOption{DisallowDuplicateFieldNames: *disallowDuplicates}
+	var invalid bool
+	report := func(prefix string, err error) {
+		for _, err := range unwrapJoined(err) {
+			invalid = true
+			fmt.Println(prefix + err.Error())
+		}
+	}
+	switch {
+	case in.kind == inputType:
+		report("", spantype.ValidateWithOption(in.typ, opts))
+	case in.kind == inputParams:
+		for _, name := range sortedKeys(in.params) {
+			report("@"+name+": ", spantype.ValidateWithOption(in.params[name], opts))
+		}
+	default:
+		report("", spantype.ValidateStructFields(in.fields, opts))
+	}
+
+	if invalid {
+		return errFindings
+	}
+	return nil
+}
+
+// unwrapJoined returns the errors joined by errors.Join.
+func unwrapJoined(err error) []error {
+	if err == nil {
+		return nil
+	}
+	var joined interface{ Unwrap() []error }
+	if errors.As(err, &joined) {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,239 @@
+package spantype
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+)
+
+// Dialect is a Cloud Spanner database dialect.
+type Dialect int
+
+const (
+	// DialectGoogleSQL is the GoogleSQL dialect.
+	DialectGoogleSQL Dialect = iota
+	// DialectPostgreSQL is the PostgreSQL dialect.
+	DialectPostgreSQL
+)
+
+// DDLOption is an option for FormatCreateTable.
+type DDLOption struct {
+	// Dialect is the dialect of the generated DDL.
+	Dialect Dialect
+	// PrimaryKey is the list of primary key column names.
+	PrimaryKey []string
+}
+
+// FormatColumnType formats Cloud Spanner type as a column type of `CREATE TABLE` in the given dialect.
+// e.g. `STRING(MAX)`, `ARRAY<BYTES(MAX)>`, `examples.Book` in GoogleSQL, `character varying`, `bigint[]` in PostgreSQL.
+// It returns an error when the type is not allowed as a column type, e.g. `STRUCT` or nested `ARRAY`.
+func FormatColumnType(typ *sppb.Type, dialect Dialect) (string, error) {
+	switch dialect {
+	case DialectGoogleSQL:
+		return formatGoogleSQLColumnType(typ, false)
+	case DialectPostgreSQL:
+		return formatPostgreSQLColumnType(typ, false)
+	default:
+		return "", fmt.Errorf("unknown dialect: %v", int(dialect))
+	}
+}
+
+// scalarColumnTypes is the PostgreSQL names of scalar type codes allowed as column types.
+// GoogleSQL names are the type code names formatted by FormatTypeCode.
+// Type codes in neither scalarColumnTypes nor nonColumnTypeCodes are not known to this package.
+var scalarColumnTypes = map[sppb.TypeCode]string{
+	sppb.TypeCode_BOOL:      "boolean",
+	sppb.TypeCode_INT64:     "bigint",
+	sppb.TypeCode_FLOAT32:   "real",
+	sppb.TypeCode_FLOAT64:   "double precision",
+	sppb.TypeCode_TIMESTAMP: "timestamp with time zone",
+	sppb.TypeCode_DATE:      "date",
+	sppb.TypeCode_STRING:    "character varying",
+	sppb.TypeCode_BYTES:     "bytea",
+	sppb.TypeCode_NUMERIC:   "numeric",
+	sppb.TypeCode_JSON:      "jsonb",
+	sppb.TypeCode_UUID:      "uuid",
+}
+
+// nonColumnTypeCodes is the scalar type codes which are not allowed as column types.
+var nonColumnTypeCodes = map[sppb.TypeCode]bool{
+	sppb.TypeCode_TYPE_CODE_UNSPECIFIED: true,
+	sppb.TypeCode_INTERVAL:              true,
+}
+
+func formatGoogleSQLColumnType(typ *sppb.Type, inArray bool) (string, error) {
+	if typ.GetTypeAnnotation() != sppb.TypeAnnotationCode_TYPE_ANNOTATION_CODE_UNSPECIFIED {
+		return "", fmt.Errorf("%v is not allowed in GoogleSQL column", typ.GetTypeAnnotation())
+	}
+
+	switch code := typ.GetCode(); code {
+	case sppb.TypeCode_STRING, sppb.TypeCode_BYTES:
+		return FormatTypeCode(code, UnknownModeVerbose) + "(MAX)", nil
+	case sppb.TypeCode_PROTO, sppb.TypeCode_ENUM:
+		if typ.GetProtoTypeFqn() == "" {
+			return "", fmt.Errorf("%v without proto_type_fqn is not allowed in column", code)
+		}
+		return FormatProtoEnum(typ, ProtoEnumModeFull), nil
+	case sppb.TypeCode_ARRAY:
+		if inArray {
+			return "", errors.New("nested ARRAY is not allowed in column")
+		}
+		elem, err := formatGoogleSQLColumnType(typ.GetArrayElementType(), true)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("ARRAY<%v>", elem), nil
+	default:
+		if _, ok := scalarColumnTypes[code]; ok {
+			return FormatTypeCode(code, UnknownModeVerbose), nil
+		}
+		return "", fmt.Errorf("%v is not allowed in column", FormatTypeCode(code, UnknownModeVerbose))
+	}
+}
+
+func formatPostgreSQLColumnType(typ *sppb.Type, inArray bool) (string, error) {
+	if typ.GetTypeAnnotation() == sppb.TypeAnnotationCode_PG_OID {
+		return "", errors.New("PG_OID is not allowed in column")
+	}
+
+	switch code := typ.GetCode(); code {
+	case sppb.TypeCode_ARRAY:
+		if inArray {
+			return "", errors.New("nested ARRAY is not allowed in column")
+		}
+		elem, err := formatPostgreSQLColumnType(typ.GetArrayElementType(), true)
+		if err != nil {
+			return "", err
+		}
+		return elem + "[]", nil
+	default:
+		if name, ok := scalarColumnTypes[code]; ok {
+			return name, nil
+		}
+		return "", fmt.Errorf("%v is not allowed in PostgreSQL column", FormatTypeCode(code, UnknownModeVerbose))
+	}
+}
+
+// FormatCreateTable formats `CREATE TABLE` statement from the table name and the row type.
+// e.g. `CREATE TABLE t (id INT64, name STRING(MAX)) PRIMARY KEY (id)` in GoogleSQL,
+// `CREATE TABLE t (id bigint, name character varying, PRIMARY KEY (id))` in PostgreSQL.
+// Identifiers are quoted only when necessary.
+func FormatCreateTable(table string, rowType *sppb.StructType, opts DDLOption) (string, error) {
+	if table == "" {
+		return "", errors.New("table name is empty")
+	}
+
+	columns := make(map[string]bool)
+	var defs []string
+	for i, field := range rowType.GetFields() {
+		name := field.GetName()
+		if name == "" {
+			return "", fmt.Errorf("column %d: name is empty", i)
+		}
+		if columns[name] {
+			return "", fmt.Errorf("column %q: duplicate column name", name)
+		}
+		columns[name] = true
+
+		typeStr, err := FormatColumnType(field.GetType(), opts.Dialect)
+		if err != nil {
+			return "", fmt.Errorf("column %q: %w", name, err)
+		}
+		defs = append(defs, quoteIdentifier(name, opts.Dialect)+" "+typeStr)
+	}
+	if len(defs) == 0 {
+		return "", errors.New("no columns")
+	}
+
+	var keys []string
+	for _, key := range opts.PrimaryKey {
+		if !columns[key] {
+			return "", fmt.Errorf("primary key column %q: not found", key)
+		}
+		keys = append(keys, quoteIdentifier(key, opts.Dialect))
+	}
+	primaryKey := fmt.Sprintf("PRIMARY KEY (%v)", strings.Join(keys, ", "))
+
+	switch opts.Dialect {
+	case DialectGoogleSQL:
+		return fmt.Sprintf("CREATE TABLE %v (%v) %v", quoteIdentifier(table, opts.Dialect), strings.Join(defs, ", "), primaryKey), nil
+	case DialectPostgreSQL:
+		if len(keys) == 0 {
+			return "", errors.New("PostgreSQL table requires primary key")
+		}
+		return fmt.Sprintf("CREATE TABLE %v (%v, %v)", quoteIdentifier(table, opts.Dialect), strings.Join(defs, ", "), primaryKey), nil
+	default:
+		return "", fmt.Errorf("unknown dialect: %v", int(opts.Dialect))
+	}
+}
+
+// googleSQLReservedKeywords is the list of GoogleSQL reserved keywords.
+var googleSQLReservedKeywords = toSet(
+	"ALL", "AND", "ANY", "ARRAY", "AS", "ASC", "ASSERT_ROWS_MODIFIED", "AT", "BETWEEN", "BY",
+	"CASE", "CAST", "COLLATE", "CONTAINS", "CREATE", "CROSS", "CUBE", "CURRENT", "DEFAULT", "DEFINE",
+	"DESC", "DISTINCT", "ELSE", "END", "ENUM", "ESCAPE", "EXCEPT", "EXCLUDE", "EXISTS", "EXTRACT",
+	"FALSE", "FETCH", "FOLLOWING", "FOR", "FROM", "FULL", "GROUP", "GROUPING", "GROUPS", "HASH",
+	"HAVING", "IF", "IGNORE", "IN", "INNER", "INTERSECT", "INTERVAL", "INTO", "IS", "JOIN",
+	"LATERAL", "LEFT", "LIKE", "LIMIT", "LOOKUP", "MERGE", "NATURAL", "NEW", "NO", "NOT",
+	"NULL", "NULLS", "OF", "ON", "OR", "ORDER", "OUTER", "OVER", "PARTITION", "PRECEDING",
+	"PROTO", "RANGE", "RECURSIVE", "RESPECT", "RIGHT", "ROLLUP", "ROWS", "SELECT", "SET", "SOME",
+	"STRUCT", "TABLESAMPLE", "THEN", "TO", "TREAT", "TRUE", "UNBOUNDED", "UNION", "UNNEST", "USING",
+	"WHEN", "WHERE", "WINDOW", "WITH", "WITHIN",
+)
+
+// postgreSQLReservedKeywords is the list of PostgreSQL reserved keywords.
+var postgreSQLReservedKeywords = toSet(
+	"all", "analyse", "analyze", "and", "any", "array", "as", "asc", "asymmetric", "both",
+	"case", "cast", "check", "collate", "column", "constraint", "create", "current_catalog", "current_date", "current_role",
+	"current_time", "current_timestamp", "current_user", "default", "deferrable", "desc", "distinct", "do", "else", "end",
+	"except", "false", "fetch", "for", "foreign", "from", "grant", "group", "having", "in",
+	"initially", "intersect", "into", "lateral", "leading", "limit", "localtime", "localtimestamp", "not", "null",
+	"offset", "on", "only", "or", "order", "placing", "primary", "references", "returning", "select",
+	"session_user", "some", "symmetric", "table", "then", "to", "trailing", "true", "union", "unique",
+	"user", "using", "variadic", "when", "where", "window", "with",
+)
+
+func toSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// quoteIdentifier quotes the identifier when it is a reserved keyword or contains characters
+// which can't be used in an unquoted identifier.
+func quoteIdentifier(name string, dialect Dialect) string {
+	switch dialect {
+	case DialectPostgreSQL:
+		if isIdentifier(name, false) && !postgreSQLReservedKeywords[name] {
+			return name
+		}
+		return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+	default:
+		if isIdentifier(name, true) && !googleSQLReservedKeywords[strings.ToUpper(name)] {
+			return name
+		}
+		return "`" + strings.ReplaceAll(name, "`", "\\`") + "`"
+	}
+}
+
+// isIdentifier reports whether name can be used as an unquoted identifier.
+// PostgreSQL folds unquoted identifiers to lower case, so upper case letters are allowed only if allowUpper is true.
+func isIdentifier(name string, allowUpper bool) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', 'a' <= r && r <= 'z':
+		case allowUpper && 'A' <= r && r <= 'Z':
+		case i > 0 && '0' <= r && r <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
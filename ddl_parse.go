@@ -0,0 +1,724 @@
+package spantype
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ParseOption is an option for ParseType, ParseCreateTable and ParseDDL.
+type ParseOption struct {
+	// Dialect is the dialect of the input.
+	Dialect Dialect
+	// Resolver distinguishes `ENUM` from `PROTO` for bare fully-qualified names in GoogleSQL.
+	// Bare names are parsed as `PROTO` unless Resolver resolves them as enums.
+	// Unqualified bare names are accepted only if Resolver resolves them, so unknown types are not mistaken for `PROTO`.
+	Resolver DescriptorResolver
+}
+
+// LengthMax is Column.Length of `STRING(MAX)` and `BYTES(MAX)`.
+const LengthMax int64 = -1
+
+// Table is a table definition parsed from `CREATE TABLE` statement.
+type Table struct {
+	// Name is the table name. Named schema is joined by `.`. e.g. `sch.Singers`
+	Name string
+	// RowType is the row type of the table in column order.
+	RowType *sppb.StructType
+	// Columns is the column metadata which is not represented in RowType, in the same order as RowType.
+	Columns []*Column
+	// PrimaryKey is the list of primary key column names.
+	PrimaryKey []string
+}
+
+// Column is column metadata parsed from column definition.
+type Column struct {
+	// Name is the column name.
+	Name string
+	// NotNull is true if the column is `NOT NULL`.
+	NotNull bool
+	// Length is the length of `STRING(length)` or `BYTES(length)`, including the element type of `ARRAY`.
+	// It is LengthMax for `MAX`, and 0 if it is not specified.
+	Length int64
+	// Default is the expression of `DEFAULT`, or empty if it doesn't have default value.
+	Default string
+	// Generated is the expression of generated column, or empty if it is not a generated column.
+	Generated string
+	// Stored is true if the generated column is `STORED`.
+	Stored bool
+	// Identity is true if the column is an identity column.
+	Identity bool
+	// Hidden is true if the column is `HIDDEN`.
+	Hidden bool
+}
+
+// ParseCreateTable parses a single `CREATE TABLE` statement.
+func ParseCreateTable(ddl string, opts ParseOption) (*Table, error) {
+	stmts, err := splitStatements(ddl, opts.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	if len(stmts) != 1 {
+		return nil, fmt.Errorf("expected single statement, but %d statements", len(stmts))
+	}
+	if !isCreateTable(stmts[0]) {
+		return nil, errors.New("not a CREATE TABLE statement")
+	}
+	return newParser(ddl, stmts[0], opts).parseCreateTable()
+}
+
+// ParseDDL parses all `CREATE TABLE` statements in semicolon-separated DDL statements. e.g. schema files.
+// Other statements are ignored.
+func ParseDDL(ddl string, opts ParseOption) ([]*Table, error) {
+	stmts, err := splitStatements(ddl, opts.Dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []*Table
+	for _, stmt := range stmts {
+		if !isCreateTable(stmt) {
+			continue
+		}
+		table, err := newParser(ddl, stmt, opts).parseCreateTable()
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// ParseType parses a type in the dialect. e.g. `ARRAY<STRING(MAX)>`, `STRUCT<n INT64>`, `PROTO<examples.Book>` in GoogleSQL,
+// `character varying[]` in PostgreSQL. Lengths are accepted but dropped.
+func ParseType(s string, opts ParseOption) (*sppb.Type, error) {
+	tokens, err := tokenize(s, opts.Dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	p := newParser(s, tokens, opts)
+	typ, _, err := p.parseColumnType()
+	if err != nil {
+		return nil, err
+	}
+	if typ == nil {
+		return nil, errors.New("TOKENLIST is not supported")
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, p.errorf("expected EOF")
+	}
+	return typ, nil
+}
+
+// splitStatements tokenizes src and splits it by top-level `;`.
+// Each statement is terminated by a tokenEOF token.
+func splitStatements(src string, dialect Dialect) ([][]token, error) {
+	tokens, err := tokenize(src, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	var stmts [][]token
+	var depth, start int
+	for i, tok := range tokens {
+		switch {
+		case tok.isSymbol("("):
+			depth++
+		case tok.isSymbol(")"):
+			depth--
+		case tok.isSymbol(";") && depth == 0, tok.kind == tokenEOF:
+			if i > start {
+				stmt := append(tokens[start:i:i], token{kind: tokenEOF, pos: tok.pos, end: tok.pos})
+				stmts = append(stmts, stmt)
+			}
+			start = i + 1
+		}
+	}
+	return stmts, nil
+}
+
+func isCreateTable(stmt []token) bool {
+	return len(stmt) > 2 && stmt[0].isKeyword("CREATE") && stmt[1].isKeyword("TABLE")
+}
+
+type parser struct {
+	src    string
+	tokens []token
+	pos    int
+	opts   ParseOption
+}
+
+func newParser(src string, tokens []token, opts ParseOption) *parser {
+	return &parser{src: src, tokens: tokens, opts: opts}
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekAt(n int) token {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	tok := p.peek()
+	near := tok.text
+	if tok.kind == tokenEOF {
+		near = "EOF"
+	}
+	return fmt.Errorf("syntax error at offset %d near %q: %v", tok.pos, near, fmt.Sprintf(format, args...))
+}
+
+// acceptKeyword consumes the given keywords if the next tokens match all of them.
+func (p *parser) acceptKeyword(kws ...string) bool {
+	for i, kw := range kws {
+		if !p.peekAt(i).isKeyword(kw) {
+			return false
+		}
+	}
+	p.pos += len(kws)
+	return true
+}
+
+func (p *parser) expectKeyword(kws ...string) error {
+	if !p.acceptKeyword(kws...) {
+		return p.errorf("expected %v", strings.Join(kws, " "))
+	}
+	return nil
+}
+
+func (p *parser) acceptSymbol(s string) bool {
+	if p.peek().isSymbol(s) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectSymbol(s string) error {
+	if !p.acceptSymbol(s) {
+		return p.errorf("expected %q", s)
+	}
+	return nil
+}
+
+// parseIdent parses an identifier. Unquoted identifiers are folded to lower case in PostgreSQL.
+func (p *parser) parseIdent() (string, error) {
+	switch tok := p.peek(); tok.kind {
+	case tokenIdent:
+		p.next()
+		if p.opts.Dialect == DialectPostgreSQL {
+			return strings.ToLower(tok.text), nil
+		}
+		return tok.text, nil
+	case tokenQuotedIdent:
+		p.next()
+		return tok.text, nil
+	default:
+		return "", p.errorf("expected identifier")
+	}
+}
+
+// parsePath parses dot-separated identifiers.
+func (p *parser) parsePath() (string, error) {
+	var idents []string
+	for {
+		ident, err := p.parseIdent()
+		if err != nil {
+			return "", err
+		}
+		idents = append(idents, ident)
+		if !p.acceptSymbol(".") {
+			return strings.Join(idents, "."), nil
+		}
+	}
+}
+
+// skipParens skips balanced parentheses starting at `(` and returns the source text inside them.
+func (p *parser) skipParens() (string, error) {
+	open := p.peek()
+	if err := p.expectSymbol("("); err != nil {
+		return "", err
+	}
+	for depth := 1; ; {
+		tok := p.next()
+		switch {
+		case tok.kind == tokenEOF:
+			return "", p.errorf("unclosed parenthesis")
+		case tok.isSymbol("("):
+			depth++
+		case tok.isSymbol(")"):
+			depth--
+			if depth == 0 {
+				return strings.TrimSpace(p.src[open.end:tok.pos]), nil
+			}
+		}
+	}
+}
+
+// skipItem skips a single token, or balanced parentheses.
+func (p *parser) skipItem() error {
+	if p.peek().isSymbol("(") {
+		_, err := p.skipParens()
+		return err
+	}
+	if p.peek().kind == tokenEOF {
+		return p.errorf("unexpected EOF")
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) atElementEnd() bool {
+	tok := p.peek()
+	return tok.isSymbol(",") || tok.isSymbol(")") || tok.kind == tokenEOF
+}
+
+func (p *parser) parseCreateTable() (*Table, error) {
+	if err := p.expectKeyword("CREATE", "TABLE"); err != nil {
+		return nil, err
+	}
+	p.acceptKeyword("IF", "NOT", "EXISTS")
+
+	name, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+
+	table := &Table{Name: name, RowType: &sppb.StructType{}}
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	for !p.peek().isSymbol(")") {
+		if err := p.parseTableElement(table); err != nil {
+			return nil, err
+		}
+		if !p.acceptSymbol(",") {
+			break
+		}
+	}
+	if err := p.expectSymbol(")"); err != nil {
+		return nil, err
+	}
+
+	if p.opts.Dialect == DialectGoogleSQL && p.acceptKeyword("PRIMARY", "KEY") {
+		keys, err := p.parseKeyList()
+		if err != nil {
+			return nil, err
+		}
+		table.PrimaryKey = append(table.PrimaryKey, keys...)
+	}
+
+	// INTERLEAVE IN PARENT, ROW DELETION POLICY, TTL and others don't affect the row type.
+	return table, nil
+}
+
+func (p *parser) parseTableElement(table *Table) error {
+	tok := p.peek()
+	switch {
+	case tok.isKeyword("CONSTRAINT"), tok.isKeyword("FOREIGN"), tok.isKeyword("CHECK"),
+		tok.isKeyword("UNIQUE"), tok.isKeyword("SYNONYM"):
+		for !p.atElementEnd() {
+			if err := p.skipItem(); err != nil {
+				return err
+			}
+		}
+		return nil
+	case tok.isKeyword("PRIMARY") && p.peekAt(1).isKeyword("KEY"):
+		p.pos += 2
+		keys, err := p.parseKeyList()
+		if err != nil {
+			return err
+		}
+		table.PrimaryKey = append(table.PrimaryKey, keys...)
+		return nil
+	}
+
+	return p.parseColumnDef(table)
+}
+
+func (p *parser) parseKeyList() ([]string, error) {
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	var keys []string
+	for !p.peek().isSymbol(")") {
+		key, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		_ = p.acceptKeyword("ASC") || p.acceptKeyword("DESC")
+		if !p.acceptSymbol(",") {
+			break
+		}
+	}
+	if err := p.expectSymbol(")"); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (p *parser) parseColumnDef(table *Table) error {
+	name, err := p.parseIdent()
+	if err != nil {
+		return err
+	}
+
+	typ, length, err := p.parseColumnType()
+	if err != nil {
+		return fmt.Errorf("column %q: %w", name, err)
+	}
+
+	col := &Column{Name: name, Length: length}
+	for !p.atElementEnd() {
+		switch {
+		case p.acceptKeyword("NOT", "NULL"):
+			col.NotNull = true
+		case p.acceptKeyword("NULL"):
+		case p.acceptKeyword("HIDDEN"):
+			col.Hidden = true
+		case p.acceptKeyword("STORED"):
+			col.Stored = true
+		case p.acceptKeyword("PRIMARY", "KEY"):
+			table.PrimaryKey = append(table.PrimaryKey, name)
+		case p.acceptKeyword("DEFAULT"):
+			if col.Default, err = p.parseDefault(); err != nil {
+				return err
+			}
+		case p.acceptKeyword("AS"), p.acceptKeyword("GENERATED", "ALWAYS", "AS"):
+			if p.acceptKeyword("IDENTITY") {
+				col.Identity = true
+				continue
+			}
+			if col.Generated, err = p.skipParens(); err != nil {
+				return err
+			}
+		case p.acceptKeyword("GENERATED", "BY", "DEFAULT", "AS", "IDENTITY"), p.acceptKeyword("AUTO_INCREMENT"):
+			col.Identity = true
+		default:
+			// OPTIONS, REFERENCES, CHECK and other clauses are skipped.
+			if err := p.skipItem(); err != nil {
+				return err
+			}
+		}
+	}
+
+	// TOKENLIST columns are not visible in row types.
+	if typ == nil {
+		return nil
+	}
+
+	table.RowType.Fields = append(table.RowType.Fields, &sppb.StructType_Field{Name: name, Type: typ})
+	table.Columns = append(table.Columns, col)
+	return nil
+}
+
+// parseDefault parses the expression of `DEFAULT`.
+// It is always parenthesized in GoogleSQL, but it is not in PostgreSQL.
+func (p *parser) parseDefault() (string, error) {
+	if p.opts.Dialect == DialectGoogleSQL {
+		return p.skipParens()
+	}
+
+	start, end := p.peek().pos, -1
+	for first := true; !p.atElementEnd(); first = false {
+		tok := p.peek()
+		if !first && (tok.isKeyword("NOT") || tok.isKeyword("NULL") || tok.isKeyword("PRIMARY") ||
+			tok.isKeyword("GENERATED") || tok.isKeyword("CHECK") || tok.isKeyword("REFERENCES") ||
+			tok.isKeyword("CONSTRAINT") || tok.isKeyword("UNIQUE")) {
+			break
+		}
+		if err := p.skipItem(); err != nil {
+			return "", err
+		}
+		end = p.tokens[p.pos-1].end
+	}
+	if end < 0 {
+		return "", p.errorf("expected expression")
+	}
+	return p.src[start:end], nil
+}
+
+// parseColumnType parses a column type, and returns the type and the length of `STRING` or `BYTES`.
+// It returns nil type for `TOKENLIST`.
+func (p *parser) parseColumnType() (*sppb.Type, int64, error) {
+	if p.opts.Dialect == DialectPostgreSQL {
+		return p.parsePostgreSQLType()
+	}
+	return p.parseGoogleSQLType()
+}
+
+var googleSQLSimpleTypes = map[string]sppb.TypeCode{
+	"BOOL":      sppb.TypeCode_BOOL,
+	"INT64":     sppb.TypeCode_INT64,
+	"FLOAT32":   sppb.TypeCode_FLOAT32,
+	"FLOAT64":   sppb.TypeCode_FLOAT64,
+	"NUMERIC":   sppb.TypeCode_NUMERIC,
+	"STRING":    sppb.TypeCode_STRING,
+	"BYTES":     sppb.TypeCode_BYTES,
+	"JSON":      sppb.TypeCode_JSON,
+	"DATE":      sppb.TypeCode_DATE,
+	"TIMESTAMP": sppb.TypeCode_TIMESTAMP,
+	"INTERVAL":  sppb.TypeCode_INTERVAL,
+	"UUID":      sppb.TypeCode_UUID,
+}
+
+func (p *parser) parseGoogleSQLType() (*sppb.Type, int64, error) {
+	tok := p.peek()
+	if tok.kind == tokenIdent {
+		upper := strings.ToUpper(tok.text)
+		switch upper {
+		case "ARRAY":
+			p.next()
+			if err := p.expectSymbol("<"); err != nil {
+				return nil, 0, err
+			}
+			elem, length, err := p.parseGoogleSQLType()
+			if err != nil {
+				return nil, 0, err
+			}
+			if elem == nil {
+				return nil, 0, p.errorf("ARRAY<TOKENLIST> is not supported")
+			}
+			if err := p.expectSymbol(">"); err != nil {
+				return nil, 0, err
+			}
+			// e.g. ARRAY<FLOAT32>(vector_length=>128)
+			if p.peek().isSymbol("(") {
+				if _, err := p.skipParens(); err != nil {
+					return nil, 0, err
+				}
+			}
+			return &sppb.Type{Code: sppb.TypeCode_ARRAY, ArrayElementType: elem}, length, nil
+		case "STRUCT":
+			p.next()
+			typ, err := p.parseStructFields()
+			return typ, 0, err
+		case "PROTO", "ENUM":
+			if !p.peekAt(1).isSymbol("<") {
+				break
+			}
+			p.pos += 2
+			fqn, err := p.parsePath()
+			if err != nil {
+				return nil, 0, err
+			}
+			if err := p.expectSymbol(">"); err != nil {
+				return nil, 0, err
+			}
+			return &sppb.Type{Code: sppb.TypeCode(sppb.TypeCode_value[upper]), ProtoTypeFqn: fqn}, 0, nil
+		case "TOKENLIST":
+			p.next()
+			return nil, 0, nil
+		}
+
+		if code, ok := googleSQLSimpleTypes[upper]; ok && !p.peekAt(1).isSymbol(".") {
+			p.next()
+			var length int64
+			if (code == sppb.TypeCode_STRING || code == sppb.TypeCode_BYTES) && p.peek().isSymbol("(") {
+				var err error
+				if length, err = p.parseLength(); err != nil {
+					return nil, 0, err
+				}
+			}
+			return &sppb.Type{Code: code}, length, nil
+		}
+	}
+
+	// Bare fully-qualified name of PROTO or ENUM. e.g. examples.Book, `examples.Book`
+	start := p.pos
+	fqn, err := p.parsePath()
+	if err != nil {
+		return nil, 0, p.errorf("expected type")
+	}
+	typ := p.protoOrEnumType(fqn)
+	if typ == nil {
+		p.pos = start
+		return nil, 0, p.errorf("unknown type: %v", fqn)
+	}
+	return typ, 0, nil
+}
+
+// protoOrEnumType returns `PROTO` or `ENUM` type of the bare name.
+// It returns nil for unqualified names which Resolver doesn't resolve, e.g. `INTEGER` or `INT46`.
+func (p *parser) protoOrEnumType(fqn string) *sppb.Type {
+	var desc protoreflect.Descriptor
+	if p.opts.Resolver != nil {
+		if d, err := p.opts.Resolver.FindDescriptorByName(protoreflect.FullName(fqn)); err == nil {
+			desc = d
+		}
+	}
+	switch desc.(type) {
+	case protoreflect.EnumDescriptor:
+		return &sppb.Type{Code: sppb.TypeCode_ENUM, ProtoTypeFqn: fqn}
+	case nil:
+		if !strings.Contains(fqn, ".") {
+			return nil
+		}
+	}
+	return &sppb.Type{Code: sppb.TypeCode_PROTO, ProtoTypeFqn: fqn}
+}
+
+// parseStructFields parses `<[name] type, ...>` after `STRUCT`.
+func (p *parser) parseStructFields() (*sppb.Type, error) {
+	if err := p.expectSymbol("<"); err != nil {
+		return nil, err
+	}
+
+	typ := &sppb.Type{Code: sppb.TypeCode_STRUCT, StructType: &sppb.StructType{}}
+	for !p.peek().isSymbol(">") {
+		var name string
+		// A field has a name if an identifier is followed by another type token.
+		if first, second := p.peek(), p.peekAt(1); (first.kind == tokenIdent || first.kind == tokenQuotedIdent) &&
+			(second.kind == tokenIdent || second.kind == tokenQuotedIdent) {
+			name, _ = p.parseIdent()
+		}
+
+		fieldType, _, err := p.parseGoogleSQLType()
+		if err != nil {
+			return nil, err
+		}
+		if fieldType == nil {
+			return nil, p.errorf("TOKENLIST is not supported in STRUCT")
+		}
+		typ.StructType.Fields = append(typ.StructType.Fields, &sppb.StructType_Field{Name: name, Type: fieldType})
+		if !p.acceptSymbol(",") {
+			break
+		}
+	}
+	if err := p.expectSymbol(">"); err != nil {
+		return nil, err
+	}
+	return typ, nil
+}
+
+// parseLength parses `(MAX)` or `(length)`.
+func (p *parser) parseLength() (int64, error) {
+	if err := p.expectSymbol("("); err != nil {
+		return 0, err
+	}
+
+	var length int64
+	if p.acceptKeyword("MAX") {
+		length = LengthMax
+	} else {
+		tok := p.peek()
+		if tok.kind != tokenNumber {
+			return 0, p.errorf("expected length")
+		}
+		n, err := strconv.ParseInt(tok.text, 0, 64)
+		if err != nil {
+			return 0, p.errorf("invalid length: %v", err)
+		}
+		p.next()
+		length = n
+	}
+
+	if err := p.expectSymbol(")"); err != nil {
+		return 0, err
+	}
+	return length, nil
+}
+
+var postgreSQLTypes = map[string]*sppb.Type{
+	"bool":                     {Code: sppb.TypeCode_BOOL},
+	"boolean":                  {Code: sppb.TypeCode_BOOL},
+	"bigint":                   {Code: sppb.TypeCode_INT64},
+	"int8":                     {Code: sppb.TypeCode_INT64},
+	"real":                     {Code: sppb.TypeCode_FLOAT32},
+	"float4":                   {Code: sppb.TypeCode_FLOAT32},
+	"double precision":         {Code: sppb.TypeCode_FLOAT64},
+	"float8":                   {Code: sppb.TypeCode_FLOAT64},
+	"numeric":                  {Code: sppb.TypeCode_NUMERIC, TypeAnnotation: sppb.TypeAnnotationCode_PG_NUMERIC},
+	"decimal":                  {Code: sppb.TypeCode_NUMERIC, TypeAnnotation: sppb.TypeAnnotationCode_PG_NUMERIC},
+	"varchar":                  {Code: sppb.TypeCode_STRING},
+	"character varying":        {Code: sppb.TypeCode_STRING},
+	"text":                     {Code: sppb.TypeCode_STRING},
+	"bytea":                    {Code: sppb.TypeCode_BYTES},
+	"date":                     {Code: sppb.TypeCode_DATE},
+	"timestamptz":              {Code: sppb.TypeCode_TIMESTAMP},
+	"timestamp with time zone": {Code: sppb.TypeCode_TIMESTAMP},
+	"jsonb":                    {Code: sppb.TypeCode_JSON, TypeAnnotation: sppb.TypeAnnotationCode_PG_JSONB},
+	"uuid":                     {Code: sppb.TypeCode_UUID},
+	"interval":                 {Code: sppb.TypeCode_INTERVAL},
+}
+
+// postgreSQLMultiWordTypes is the list of type names which consist of multiple words.
+var postgreSQLMultiWordTypes = [][]string{
+	{"double", "precision"},
+	{"character", "varying"},
+	{"timestamp", "with", "time", "zone"},
+}
+
+func (p *parser) parsePostgreSQLType() (*sppb.Type, int64, error) {
+	var name string
+	for _, words := range postgreSQLMultiWordTypes {
+		if p.acceptKeyword(words...) {
+			name = strings.Join(words, " ")
+			break
+		}
+	}
+	start := p.pos
+	if name == "" {
+		path, err := p.parsePath()
+		if err != nil {
+			return nil, 0, p.errorf("expected type")
+		}
+		name = path
+	}
+
+	if name == "spanner.tokenlist" {
+		return nil, 0, nil
+	}
+
+	base, ok := postgreSQLTypes[name]
+	if !ok {
+		p.pos = start
+		return nil, 0, p.errorf("unknown type: %v", name)
+	}
+	typ := &sppb.Type{Code: base.GetCode(), TypeAnnotation: base.GetTypeAnnotation()}
+
+	var length int64
+	if p.peek().isSymbol("(") {
+		if typ.GetCode() == sppb.TypeCode_STRING {
+			var err error
+			if length, err = p.parseLength(); err != nil {
+				return nil, 0, err
+			}
+		} else if _, err := p.skipParens(); err != nil {
+			// e.g. numeric(10, 2)
+			return nil, 0, err
+		}
+	}
+
+	// e.g. bigint[], bigint[10], bigint[][]
+	for p.acceptSymbol("[") {
+		if p.peek().kind == tokenNumber {
+			p.next()
+		}
+		if err := p.expectSymbol("]"); err != nil {
+			return nil, 0, err
+		}
+		if typ.GetCode() != sppb.TypeCode_ARRAY {
+			typ = &sppb.Type{Code: sppb.TypeCode_ARRAY, ArrayElementType: typ}
+		}
+	}
+	return typ, length, nil
+}
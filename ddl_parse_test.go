@@ -0,0 +1,322 @@
+package spantype
+
+import (
+	"fmt"
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	. "github.com/apstndb/spantype/typector"
+)
+
+// packageResolver resolves names in the package, e.g. `Book` as `examples.shop.Book`.
+type packageResolver struct {
+	files *protoregistry.Files
+	pkg   string
+}
+
+func (r packageResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	return r.files.FindDescriptorByName(protoreflect.FullName(r.pkg).Append(protoreflect.Name(name)))
+}
+
+func TestParseDDL(t *testing.T) {
+	files := testFiles(t)
+
+	tests := []struct {
+		desc    string
+		ddl     string
+		opts    ParseOption
+		want    []*Table
+		wantErr bool
+	}{
+		{
+			desc: "GoogleSQL",
+			ddl: `
+-- Singers table
+CREATE TABLE IF NOT EXISTS Singers (
+  SingerId INT64 NOT NULL,
+  Name STRING(1024) OPTIONS (allow_commit_timestamp = false),
+  Tags ARRAY<STRING(MAX)> DEFAULT (["a", "b"]),
+  Book examples.shop.Book,
+  Genre ` + "`examples.shop.Genre`" + `,
+  Embedding ARRAY<FLOAT32>(vector_length=>128),
+  NameLength INT64 AS (CHAR_LENGTH(Name)) STORED,
+  Name_Tokens TOKENLIST AS (TOKENIZE_FULLTEXT(Name)) HIDDEN,
+  CONSTRAINT FK_Book FOREIGN KEY (SingerId) REFERENCES Books (BookId),
+) PRIMARY KEY (SingerId DESC), ROW DELETION POLICY (OLDER_THAN(CreatedAt, INTERVAL 1 DAY));
+
+CREATE INDEX SingersByName ON Singers(Name);
+
+# Albums table
+CREATE TABLE Albums (
+  SingerId INT64 NOT NULL,
+  AlbumId INT64 NOT NULL,
+) PRIMARY KEY (SingerId, AlbumId),
+  INTERLEAVE IN PARENT Singers ON DELETE CASCADE;
+`,
+			opts: ParseOption{Dialect: DialectGoogleSQL, Resolver: files},
+			want: []*Table{
+				{
+					Name: "Singers",
+					RowType: &sppb.StructType{Fields: []*sppb.StructType_Field{
+						NameCodeToStructTypeField("SingerId", sppb.TypeCode_INT64),
+						NameCodeToStructTypeField("Name", sppb.TypeCode_STRING),
+						NameTypeToStructTypeField("Tags", ElemCodeToArrayType(sppb.TypeCode_STRING)),
+						NameTypeToStructTypeField("Book", FQNToProtoType("examples.shop.Book")),
+						NameTypeToStructTypeField("Genre", FQNToEnumType("examples.shop.Genre")),
+						NameTypeToStructTypeField("Embedding", ElemCodeToArrayType(sppb.TypeCode_FLOAT32)),
+						NameCodeToStructTypeField("NameLength", sppb.TypeCode_INT64),
+					}},
+					Columns: []*Column{
+						{Name: "SingerId", NotNull: true},
+						{Name: "Name", Length: 1024},
+						{Name: "Tags", Length: LengthMax, Default: `["a", "b"]`},
+						{Name: "Book"},
+						{Name: "Genre"},
+						{Name: "Embedding"},
+						{Name: "NameLength", Generated: "CHAR_LENGTH(Name)", Stored: true},
+					},
+					PrimaryKey: []string{"SingerId"},
+				},
+				{
+					Name: "Albums",
+					RowType: &sppb.StructType{Fields: []*sppb.StructType_Field{
+						NameCodeToStructTypeField("SingerId", sppb.TypeCode_INT64),
+						NameCodeToStructTypeField("AlbumId", sppb.TypeCode_INT64),
+					}},
+					Columns: []*Column{
+						{Name: "SingerId", NotNull: true},
+						{Name: "AlbumId", NotNull: true},
+					},
+					PrimaryKey: []string{"SingerId", "AlbumId"},
+				},
+			},
+		},
+		{
+			desc: "PostgreSQL",
+			ddl: `
+CREATE TABLE Singers (
+  singer_id bigint GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  "FullName" varchar(1024) NOT NULL DEFAULT 'x',
+  score numeric DEFAULT 0,
+  details jsonb,
+  tags character varying[],
+  created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  ratio double precision GENERATED ALWAYS AS (score / 2) STORED,
+  tokens spanner.tokenlist GENERATED ALWAYS AS (spanner.tokenize_fulltext("FullName")) HIDDEN,
+  CHECK (score > 0)
+) INTERLEAVE IN PARENT Artists;
+`,
+			opts: ParseOption{Dialect: DialectPostgreSQL},
+			want: []*Table{
+				{
+					Name: "singers",
+					RowType: &sppb.StructType{Fields: []*sppb.StructType_Field{
+						NameCodeToStructTypeField("singer_id", sppb.TypeCode_INT64),
+						NameCodeToStructTypeField("FullName", sppb.TypeCode_STRING),
+						NameTypeToStructTypeField("score", &sppb.Type{Code: sppb.TypeCode_NUMERIC, TypeAnnotation: sppb.TypeAnnotationCode_PG_NUMERIC}),
+						NameTypeToStructTypeField("details", &sppb.Type{Code: sppb.TypeCode_JSON, TypeAnnotation: sppb.TypeAnnotationCode_PG_JSONB}),
+						NameTypeToStructTypeField("tags", ElemCodeToArrayType(sppb.TypeCode_STRING)),
+						NameCodeToStructTypeField("created_at", sppb.TypeCode_TIMESTAMP),
+						NameCodeToStructTypeField("ratio", sppb.TypeCode_FLOAT64),
+					}},
+					Columns: []*Column{
+						{Name: "singer_id", Identity: true},
+						{Name: "FullName", NotNull: true, Length: 1024, Default: "'x'"},
+						{Name: "score", Default: "0"},
+						{Name: "details"},
+						{Name: "tags"},
+						{Name: "created_at", NotNull: true, Default: "CURRENT_TIMESTAMP"},
+						{Name: "ratio", Generated: "score / 2", Stored: true},
+					},
+					PrimaryKey: []string{"singer_id"},
+				},
+			},
+		},
+		{
+			desc: "PostgreSQL table constraint",
+			ddl:  `CREATE TABLE t (a bigint, b text, PRIMARY KEY (a, b))`,
+			opts: ParseOption{Dialect: DialectPostgreSQL},
+			want: []*Table{
+				{
+					Name: "t",
+					RowType: &sppb.StructType{Fields: []*sppb.StructType_Field{
+						NameCodeToStructTypeField("a", sppb.TypeCode_INT64),
+						NameCodeToStructTypeField("b", sppb.TypeCode_STRING),
+					}},
+					Columns:    []*Column{{Name: "a"}, {Name: "b"}},
+					PrimaryKey: []string{"a", "b"},
+				},
+			},
+		},
+		{
+			desc:    "unknown type",
+			ddl:     `CREATE TABLE t (a int4) PRIMARY KEY (a)`,
+			opts:    ParseOption{Dialect: DialectPostgreSQL},
+			wantErr: true,
+		},
+		{
+			desc:    "unknown bare name",
+			ddl:     `CREATE TABLE t (a INTEGER) PRIMARY KEY (a)`,
+			opts:    ParseOption{Dialect: DialectGoogleSQL},
+			wantErr: true,
+		},
+		{
+			desc:    "unqualified name not resolved",
+			ddl:     `CREATE TABLE t (a Author) PRIMARY KEY (a)`,
+			opts:    ParseOption{Dialect: DialectGoogleSQL, Resolver: packageResolver{files: files, pkg: "examples.shop"}},
+			wantErr: true,
+		},
+		{
+			desc: "unqualified names resolved by Resolver",
+			ddl:  `CREATE TABLE t (a Book, b Genre) PRIMARY KEY (a)`,
+			opts: ParseOption{Dialect: DialectGoogleSQL, Resolver: packageResolver{files: files, pkg: "examples.shop"}},
+			want: []*Table{
+				{
+					Name: "t",
+					RowType: &sppb.StructType{Fields: []*sppb.StructType_Field{
+						NameTypeToStructTypeField("a", FQNToProtoType("Book")),
+						NameTypeToStructTypeField("b", FQNToEnumType("Genre")),
+					}},
+					Columns:    []*Column{{Name: "a"}, {Name: "b"}},
+					PrimaryKey: []string{"a"},
+				},
+			},
+		},
+		{
+			desc:    "unclosed",
+			ddl:     `CREATE TABLE t (a INT64 PRIMARY KEY (a)`,
+			opts:    ParseOption{Dialect: DialectGoogleSQL},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := ParseDDL(tt.ddl, tt.opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseDDL should fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDDL failed: %v", err)
+			}
+			if len(tt.want) != len(got) {
+				t.Fatalf("ParseDDL want %d tables, got: %d", len(tt.want), len(got))
+			}
+			for i := range tt.want {
+				want, got := tt.want[i], got[i]
+				if want.Name != got.Name {
+					t.Errorf("table %d: Name want: %v, got: %v", i, want.Name, got.Name)
+				}
+				if !proto.Equal(want.RowType, got.RowType) {
+					t.Errorf("table %d: RowType want: %v, got: %v", i, want.RowType, got.RowType)
+				}
+				if len(want.Columns) != len(got.Columns) {
+					t.Errorf("table %d: want %d columns, got: %d", i, len(want.Columns), len(got.Columns))
+				} else {
+					for j := range want.Columns {
+						if *want.Columns[j] != *got.Columns[j] {
+							t.Errorf("table %d: column %d want: %+v, got: %+v", i, j, *want.Columns[j], *got.Columns[j])
+						}
+					}
+				}
+				if fmt.Sprint(want.PrimaryKey) != fmt.Sprint(got.PrimaryKey) {
+					t.Errorf("table %d: PrimaryKey want: %v, got: %v", i, want.PrimaryKey, got.PrimaryKey)
+				}
+			}
+		})
+	}
+}
+
+func TestParseCreateTable(t *testing.T) {
+	if _, err := ParseCreateTable(`CREATE INDEX i ON t(a)`, ParseOption{}); err == nil {
+		t.Errorf("ParseCreateTable should fail for CREATE INDEX")
+	}
+	if _, err := ParseCreateTable(`CREATE TABLE a (n INT64) PRIMARY KEY (n); CREATE TABLE b (n INT64) PRIMARY KEY (n)`, ParseOption{}); err == nil {
+		t.Errorf("ParseCreateTable should fail for multiple statements")
+	}
+
+	table, err := ParseCreateTable(`CREATE TABLE t (n INT64, s STRUCT<a INT64, b ARRAY<STRUCT<INT64>>>) PRIMARY KEY (n)`, ParseOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "STRUCT<n INT64, s STRUCT<a INT64, b ARRAY<STRUCT<INT64>>>>"
+	if got := FormatTypeVerbose(StructTypeFieldsToStructType(table.RowType.GetFields())); want != got {
+		t.Errorf("ParseCreateTable want: %v, got: %v", want, got)
+	}
+}
+
+func TestParseDDLUnknownTypeError(t *testing.T) {
+	tests := []struct {
+		ddl     string
+		dialect Dialect
+		want    string
+	}{
+		{"CREATE TABLE t (a INT46) PRIMARY KEY (a)", DialectGoogleSQL, `column "a": syntax error at offset 18 near "INT46": unknown type: INT46`},
+		{"CREATE TABLE t (a int4, PRIMARY KEY (a))", DialectPostgreSQL, `column "a": syntax error at offset 18 near "int4": unknown type: int4`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ddl, func(t *testing.T) {
+			_, err := ParseDDL(tt.ddl, ParseOption{Dialect: tt.dialect})
+			if err == nil {
+				t.Fatal("ParseDDL should fail")
+			}
+			if got := err.Error(); tt.want != got {
+				t.Errorf("ParseDDL error want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseType(t *testing.T) {
+	resolver := packageResolver{files: testFiles(t), pkg: "examples.shop"}
+	tests := []struct {
+		desc     string
+		input    string
+		dialect  Dialect
+		resolver DescriptorResolver
+		want     *sppb.Type
+		wantErr  bool
+	}{
+		{desc: "STRING(MAX)", input: "STRING(MAX)", want: String()},
+		{desc: "lower case", input: "array<int64>", want: ElemTypeToArrayType(Int64())},
+		{desc: "STRUCT", input: "STRUCT<n INT64, ARRAY<STRUCT<`x y` STRING>>>", want: StructTypeFieldsToStructType([]*sppb.StructType_Field{
+			NameTypeToStructTypeField("n", Int64()),
+			TypeToUnnamedStructTypeField(ElemTypeToArrayType(NameTypeToStructType("x y", String()))),
+		})},
+		{desc: "PROTO", input: "PROTO<examples.Book>", want: FQNToProtoType("examples.Book")},
+		{desc: "ENUM", input: "ENUM<examples.Genre>", want: FQNToEnumType("examples.Genre")},
+		{desc: "bare FQN", input: "examples.Book", want: FQNToProtoType("examples.Book")},
+		{desc: "unknown bare name", input: "INTEGER", wantErr: true},
+		{desc: "typo of simple type", input: "ARRAY<INT46>", wantErr: true},
+		{desc: "unqualified name resolved as PROTO", input: "Book", resolver: resolver, want: FQNToProtoType("Book")},
+		{desc: "unqualified name resolved as ENUM", input: "Genre", resolver: resolver, want: FQNToEnumType("Genre")},
+		{desc: "unqualified name not resolved", input: "Author", resolver: resolver, wantErr: true},
+		{desc: "PostgreSQL unknown type", input: "int4", dialect: DialectPostgreSQL, wantErr: true},
+		{desc: "PostgreSQL", input: "varchar(10)[]", dialect: DialectPostgreSQL, want: ElemTypeToArrayType(String())},
+		{desc: "trailing tokens", input: "INT64 INT64", wantErr: true},
+		{desc: "unclosed", input: "ARRAY<INT64", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := ParseType(tt.input, ParseOption{Dialect: tt.dialect, Resolver: tt.resolver})
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseType should fail, got: %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseType failed: %v", err)
+			}
+			if !proto.Equal(tt.want, got) {
+				t.Errorf("ParseType want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package spantype
+
+import (
+	"strings"
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	. "github.com/apstndb/spantype/typector"
+)
+
+func TestFormatColumnType(t *testing.T) {
+	tests := []struct {
+		desc          string
+		typ           *sppb.Type
+		wantGoogleSQL string
+		wantPG        string
+	}{
+		{desc: "INT64", typ: Int64(), wantGoogleSQL: "INT64", wantPG: "bigint"},
+		{desc: "STRING", typ: String(), wantGoogleSQL: "STRING(MAX)", wantPG: "character varying"},
+		{desc: "BYTES", typ: Bytes(), wantGoogleSQL: "BYTES(MAX)", wantPG: "bytea"},
+		{desc: "TIMESTAMP", typ: Timestamp(), wantGoogleSQL: "TIMESTAMP", wantPG: "timestamp with time zone"},
+		{desc: "ARRAY<STRING>", typ: ElemTypeToArrayType(String()), wantGoogleSQL: "ARRAY<STRING(MAX)>", wantPG: "character varying[]"},
+		{desc: "PROTO", typ: FQNToProtoType("examples.Book"), wantGoogleSQL: "examples.Book"},
+		{desc: "ENUM", typ: ElemTypeToArrayType(FQNToEnumType("examples.Genre")), wantGoogleSQL: "ARRAY<examples.Genre>"},
+		{desc: "PG_NUMERIC", typ: &sppb.Type{Code: sppb.TypeCode_NUMERIC, TypeAnnotation: sppb.TypeAnnotationCode_PG_NUMERIC}, wantPG: "numeric"},
+		{desc: "PG_JSONB", typ: &sppb.Type{Code: sppb.TypeCode_JSON, TypeAnnotation: sppb.TypeAnnotationCode_PG_JSONB}, wantPG: "jsonb"},
+		{desc: "PG_OID", typ: &sppb.Type{Code: sppb.TypeCode_INT64, TypeAnnotation: sppb.TypeAnnotationCode_PG_OID}},
+		{desc: "STRUCT", typ: NameCodeToStructType("n", sppb.TypeCode_INT64)},
+		{desc: "ARRAY<ARRAY<INT64>>", typ: ElemTypeToArrayType(ElemCodeToArrayType(sppb.TypeCode_INT64))},
+		{desc: "INTERVAL", typ: Interval()},
+		{desc: "UNKNOWN", typ: CodeToSimpleType(-1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			for _, d := range []struct {
+				dialect Dialect
+				want    string
+			}{
+				{DialectGoogleSQL, tt.wantGoogleSQL},
+				{DialectPostgreSQL, tt.wantPG},
+			} {
+				got, err := FormatColumnType(tt.typ, d.dialect)
+				if d.want == "" {
+					if err == nil {
+						t.Errorf("FormatColumnType(dialect=%v) should fail, got: %v", d.dialect, got)
+					}
+					continue
+				}
+				if err != nil {
+					t.Errorf("FormatColumnType(dialect=%v) failed: %v", d.dialect, err)
+				} else if d.want != got {
+					t.Errorf("FormatColumnType(dialect=%v) want: %v, got: %v", d.dialect, d.want, got)
+				}
+			}
+		})
+	}
+}
+
+// TestFormatColumnTypeCoversTypeCodes fails when spannerpb adds a type code which FormatColumnType doesn't know.
+func TestFormatColumnTypeCoversTypeCodes(t *testing.T) {
+	for number, name := range sppb.TypeCode_name {
+		code := sppb.TypeCode(number)
+		t.Run(name, func(t *testing.T) {
+			switch code {
+			case sppb.TypeCode_ARRAY, sppb.TypeCode_STRUCT, sppb.TypeCode_PROTO, sppb.TypeCode_ENUM:
+				return
+			}
+			_, scalar := scalarColumnTypes[code]
+			if scalar == nonColumnTypeCodes[code] {
+				t.Fatalf("%v must be in exactly one of scalarColumnTypes and nonColumnTypeCodes", name)
+			}
+			for _, dialect := range []Dialect{DialectGoogleSQL, DialectPostgreSQL} {
+				got, err := FormatColumnType(CodeToSimpleType(code), dialect)
+				if scalar && err != nil {
+					t.Errorf("FormatColumnType(dialect=%v) failed: %v", dialect, err)
+				}
+				if !scalar && err == nil {
+					t.Errorf("FormatColumnType(dialect=%v) should fail, but got: %v", dialect, got)
+				}
+			}
+			// GoogleSQL column types share the type names with FormatType.
+			if got, err := FormatColumnType(CodeToSimpleType(code), DialectGoogleSQL); scalar && err == nil && !strings.HasPrefix(got, FormatTypeSimple(CodeToSimpleType(code))) {
+				t.Errorf("FormatColumnType want prefix: %v, got: %v", FormatTypeSimple(CodeToSimpleType(code)), got)
+			}
+		})
+	}
+}
+
+func TestFormatCreateTable(t *testing.T) {
+	rowType := &sppb.StructType{Fields: []*sppb.StructType_Field{
+		NameCodeToStructTypeField("col", sppb.TypeCode_INT64),
+		NameCodeToStructTypeField("name", sppb.TypeCode_STRING),
+		NameTypeToStructTypeField("tags", ElemCodeToArrayType(sppb.TypeCode_STRING)),
+		NameCodeToStructTypeField("Order", sppb.TypeCode_DATE),
+	}}
+
+	tests := []struct {
+		desc    string
+		table   string
+		rowType *sppb.StructType
+		opts    DDLOption
+		want    string
+		wantErr bool
+	}{
+		{
+			desc:    "GoogleSQL",
+			table:   "t",
+			rowType: rowType,
+			opts:    DDLOption{Dialect: DialectGoogleSQL, PrimaryKey: []string{"col", "name"}},
+			want:    "CREATE TABLE t (col INT64, name STRING(MAX), tags ARRAY<STRING(MAX)>, `Order` DATE) PRIMARY KEY (col, name)",
+		},
+		{
+			desc:    "GoogleSQL without primary key",
+			table:   "Select",
+			rowType: &sppb.StructType{Fields: []*sppb.StructType_Field{NameTypeToStructTypeField("p", FQNToProtoType("examples.Book"))}},
+			opts:    DDLOption{Dialect: DialectGoogleSQL},
+			want:    "CREATE TABLE `Select` (p examples.Book) PRIMARY KEY ()",
+		},
+		{
+			desc:    "PostgreSQL",
+			table:   "t",
+			rowType: rowType,
+			opts:    DDLOption{Dialect: DialectPostgreSQL, PrimaryKey: []string{"col"}},
+			want:    `CREATE TABLE t (col bigint, name character varying, tags character varying[], "Order" date, PRIMARY KEY (col))`,
+		},
+		{
+			desc:    "PostgreSQL without primary key",
+			table:   "t",
+			rowType: rowType,
+			opts:    DDLOption{Dialect: DialectPostgreSQL},
+			wantErr: true,
+		},
+		{
+			desc:    "unknown primary key column",
+			table:   "t",
+			rowType: rowType,
+			opts:    DDLOption{PrimaryKey: []string{"unknown"}},
+			wantErr: true,
+		},
+		{
+			desc:    "STRUCT column",
+			table:   "t",
+			rowType: &sppb.StructType{Fields: []*sppb.StructType_Field{NameTypeToStructTypeField("s", NameCodeToStructType("n", sppb.TypeCode_INT64))}},
+			wantErr: true,
+		},
+		{
+			desc:    "unnamed column",
+			table:   "t",
+			rowType: &sppb.StructType{Fields: []*sppb.StructType_Field{CodeToUnnamedStructTypeField(sppb.TypeCode_INT64)}},
+			wantErr: true,
+		},
+		{
+			desc:    "duplicate column",
+			table:   "t",
+			rowType: &sppb.StructType{Fields: []*sppb.StructType_Field{NameCodeToStructTypeField("n", sppb.TypeCode_INT64), NameCodeToStructTypeField("n", sppb.TypeCode_INT64)}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := FormatCreateTable(tt.table, tt.rowType, tt.opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("FormatCreateTable should fail, got: %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FormatCreateTable failed: %v", err)
+			}
+			if tt.want != got {
+				t.Errorf("FormatCreateTable want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
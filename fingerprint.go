@@ -0,0 +1,96 @@
+package spantype
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+)
+
+// canonicalVersion is the version of the canonical encoding.
+// It must be changed when the encoding is changed, and the encoding of a version must never change.
+const canonicalVersion = 1
+
+// FingerprintOption is an option for AppendCanonical and fingerprint functions.
+type FingerprintOption struct {
+	// IgnoreFieldNames ignores `STRUCT` field names, so `STRUCT<a INT64>` and `STRUCT<b INT64>` are equivalent.
+	IgnoreFieldNames bool
+	// IgnoreAnnotations ignores type annotations, so `NUMERIC` and `NUMERIC` with `PG_NUMERIC` are equivalent.
+	IgnoreAnnotations bool
+}
+
+// Fingerprint is a 128-bit fingerprint of a type.
+type Fingerprint [16]byte
+
+// String formats the fingerprint as a hexadecimal string.
+func (f Fingerprint) String() string {
+	return hex.EncodeToString(f[:])
+}
+
+// AppendCanonical appends the canonical binary encoding of the type to dst and returns the extended buffer.
+// The encoding depends only on the code, annotation, FQN, element type, and fields of the type,
+// so it is independent of proto field ordering and unknown fields.
+// The encoding of a type is stable across releases.
+func AppendCanonical(dst []byte, typ *sppb.Type, opts FingerprintOption) []byte {
+	return appendCanonicalType(append(dst, canonicalVersion), typ, opts)
+}
+
+// Fingerprint64 returns the first 64 bits of Fingerprint128 as a big-endian integer.
+// It is stable across releases.
+func Fingerprint64(typ *sppb.Type, opts FingerprintOption) uint64 {
+	f := Fingerprint128(typ, opts)
+	return binary.BigEndian.Uint64(f[:8])
+}
+
+// Fingerprint128 returns the first 128 bits of the SHA-256 hash of the canonical encoding of the type.
+// It is stable across releases.
+func Fingerprint128(typ *sppb.Type, opts FingerprintOption) Fingerprint {
+	bp := bufPool.Get().(*[]byte)
+	defer bufPool.Put(bp)
+
+	*bp = AppendCanonical((*bp)[:0], typ, opts)
+	return fingerprint128(*bp)
+}
+
+func fingerprint128(b []byte) Fingerprint {
+	sum := sha256.Sum256(b)
+	return Fingerprint(sum[:16])
+}
+
+func appendCanonicalType(dst []byte, typ *sppb.Type, opts FingerprintOption) []byte {
+	if typ == nil {
+		return append(dst, 0)
+	}
+	dst = append(dst, 1)
+	dst = binary.AppendVarint(dst, int64(typ.GetCode()))
+	if opts.IgnoreAnnotations {
+		dst = binary.AppendVarint(dst, 0)
+	} else {
+		dst = binary.AppendVarint(dst, int64(typ.GetTypeAnnotation()))
+	}
+	dst = appendCanonicalString(dst, typ.GetProtoTypeFqn())
+	dst = appendCanonicalType(dst, typ.GetArrayElementType(), opts)
+	if typ.GetStructType() == nil {
+		return append(dst, 0)
+	}
+	return appendCanonicalFields(append(dst, 1), typ.GetStructType().GetFields(), opts)
+}
+
+func appendCanonicalFields(dst []byte, fields []*sppb.StructType_Field, opts FingerprintOption) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(fields)))
+	for _, field := range fields {
+		if opts.IgnoreFieldNames {
+			dst = appendCanonicalString(dst, "")
+		} else {
+			dst = appendCanonicalString(dst, field.GetName())
+		}
+		dst = appendCanonicalType(dst, field.GetType(), opts)
+	}
+	return dst
+}
+
+func appendCanonicalString(dst []byte, s string) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(s)))
+	return append(dst, s...)
+}
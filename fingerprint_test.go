@@ -0,0 +1,152 @@
+package spantype
+
+import (
+	"encoding/hex"
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	. "github.com/apstndb/spantype/typector"
+)
+
+// TestFingerprintStability guarantees that the canonical encoding and fingerprints don't change across releases.
+// Never update the expected values; change canonicalVersion and add new cases instead.
+func TestFingerprintStability(t *testing.T) {
+	tests := []struct {
+		desc          string
+		typ           *sppb.Type
+		opts          FingerprintOption
+		wantCanonical string
+		want128       string
+		want64        uint64
+	}{
+		{
+			desc:          "INT64",
+			typ:           Int64(),
+			wantCanonical: "01010400000000",
+			want128:       "8135530df1f7b3b62ba0671256d53d77",
+			want64:        0x8135530df1f7b3b6,
+		},
+		{
+			desc:          "STRUCT",
+			typ:           NameTypeToStructType("arr", ElemTypeToArrayType(FQNToProtoType("examples.Book"))),
+			wantCanonical: "01011200000001010361727201100000011a000d6578616d706c65732e426f6f6b000000",
+			want128:       "90495d407c3bbb2bf94621772c7e7b44",
+			want64:        0x90495d407c3bbb2b,
+		},
+		{
+			desc:          "PG_NUMERIC",
+			typ:           &sppb.Type{Code: sppb.TypeCode_NUMERIC, TypeAnnotation: sppb.TypeAnnotationCode_PG_NUMERIC},
+			wantCanonical: "01011404000000",
+			want128:       "e9c1ab6ab09e85d6822d0f08b58e6eda",
+			want64:        0xe9c1ab6ab09e85d6,
+		},
+		{
+			desc:          "STRUCT ignoring field names",
+			typ:           NameTypeToStructType("arr", ElemTypeToArrayType(FQNToProtoType("examples.Book"))),
+			opts:          FingerprintOption{IgnoreFieldNames: true},
+			wantCanonical: "01011200000001010001100000011a000d6578616d706c65732e426f6f6b000000",
+			want128:       "ca5e3756fd8a653666b4f18514be9c57",
+			want64:        0xca5e3756fd8a6536,
+		},
+		{
+			desc:          "nil",
+			typ:           nil,
+			wantCanonical: "0100",
+			want128:       "47dc540c94ceb704a23875c11273e16b",
+			want64:        0x47dc540c94ceb704,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := hex.EncodeToString(AppendCanonical(nil, tt.typ, tt.opts)); tt.wantCanonical != got {
+				t.Errorf("AppendCanonical want: %v, got: %v", tt.wantCanonical, got)
+			}
+			if got := Fingerprint128(tt.typ, tt.opts).String(); tt.want128 != got {
+				t.Errorf("Fingerprint128 want: %v, got: %v", tt.want128, got)
+			}
+			if got := Fingerprint64(tt.typ, tt.opts); tt.want64 != got {
+				t.Errorf("Fingerprint64 want: %#x, got: %#x", tt.want64, got)
+			}
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	typ := NameTypeToStructType("arr", ElemTypeToArrayType(FQNToProtoType("examples.Book")))
+
+	// Unknown fields don't affect fingerprints.
+	withUnknown := proto.Clone(typ).(*sppb.Type)
+	withUnknown.ProtoReflect().SetUnknown(protoreflect.RawFields{0xf8, 0x3e, 0x01}) // field 999, varint 1
+	if Fingerprint128(typ, FingerprintOption{}) != Fingerprint128(withUnknown, FingerprintOption{}) {
+		t.Errorf("unknown fields should not affect Fingerprint128")
+	}
+
+	// Field order in wire format doesn't affect fingerprints.
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(typ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reordered sppb.Type
+	// code (field 1) is moved after struct_type (field 3).
+	if err := proto.Unmarshal(append(b[2:], b[:2]...), &reordered); err != nil {
+		t.Fatal(err)
+	}
+	if Fingerprint128(typ, FingerprintOption{}) != Fingerprint128(&reordered, FingerprintOption{}) {
+		t.Errorf("field order should not affect Fingerprint128")
+	}
+
+	tests := []struct {
+		desc  string
+		a, b  *sppb.Type
+		opts  FingerprintOption
+		equal bool
+	}{
+		{
+			desc: "different field names",
+			a:    NameCodeToStructType("a", sppb.TypeCode_INT64),
+			b:    NameCodeToStructType("b", sppb.TypeCode_INT64),
+		},
+		{
+			desc:  "ignore field names",
+			a:     NameCodeToStructType("a", sppb.TypeCode_INT64),
+			b:     NameCodeToStructType("b", sppb.TypeCode_INT64),
+			opts:  FingerprintOption{IgnoreFieldNames: true},
+			equal: true,
+		},
+		{
+			desc: "different annotations",
+			a:    Numeric(),
+			b:    &sppb.Type{Code: sppb.TypeCode_NUMERIC, TypeAnnotation: sppb.TypeAnnotationCode_PG_NUMERIC},
+		},
+		{
+			desc:  "ignore annotations",
+			a:     Numeric(),
+			b:     &sppb.Type{Code: sppb.TypeCode_NUMERIC, TypeAnnotation: sppb.TypeAnnotationCode_PG_NUMERIC},
+			opts:  FingerprintOption{IgnoreAnnotations: true},
+			equal: true,
+		},
+		{
+			desc: "STRUCT without fields and STRUCT without struct_type",
+			a:    StructTypeFieldsToStructType(nil),
+			b:    &sppb.Type{Code: sppb.TypeCode_STRUCT},
+		},
+		{
+			desc: "PROTO and ENUM",
+			a:    FQNToProtoType("examples.Book"),
+			b:    FQNToEnumType("examples.Book"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := Fingerprint128(tt.a, tt.opts) == Fingerprint128(tt.b, tt.opts); tt.equal != got {
+				t.Errorf("Fingerprint128 equality want: %v, got: %v", tt.equal, got)
+			}
+			if got := Fingerprint64(tt.a, tt.opts) == Fingerprint64(tt.b, tt.opts); tt.equal != got {
+				t.Errorf("Fingerprint64 equality want: %v, got: %v", tt.equal, got)
+			}
+		})
+	}
+}
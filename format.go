@@ -3,11 +3,15 @@
 package spantype
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
+	"sync"
 
 	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 // StructMode controls how STRUCT types are rendered by selecting one of
@@ -24,13 +28,14 @@ const (
 )
 
 // ProtoEnumMode controls how PROTO and ENUM types are rendered by selecting
-// base, leaf, full-name, or explicit-kind output.
+// base, leaf, package-relative, full-name, or explicit-kind output.
 type ProtoEnumMode int
 
 const (
 	// ProtoEnumModeBase formats `PROTO` and `ENUM` type as `PROTO` and `ENUM`.
 	ProtoEnumModeBase ProtoEnumMode = iota
-	// ProtoEnumModeLeaf formats `PROTO` and `ENUM` type without package name. e.g. `ProtoType`, `EnumType`
+	// ProtoEnumModeLeaf formats `PROTO` and `ENUM` type as the substring after the last dot. e.g. `ProtoType`, `EnumType`
+	// Note: Nested types lose their enclosing message name. e.g. `examples.Outer.Inner` is formatted as `Inner`.
 	ProtoEnumModeLeaf
 	// ProtoEnumModeFull formats `PROTO` and `ENUM` type as full qualified name. e.g. `examples.ProtoType`, `examples.EnumType`
 	ProtoEnumModeFull
@@ -41,6 +46,13 @@ const (
 	// e.g. `PROTO<examples.ProtoType>`, `ENUM<examples.EnumType>`.
 	// Note: It should be same format with `INFORMATION_SCHEMA.COLUMNS.SPANNER_TYPE`.
 	ProtoEnumModeFullWithKind
+	// ProtoEnumModeRelative formats `PROTO` and `ENUM` type relative to its proto package. e.g. `Outer.Inner`
+	// The package is taken from FormatOption.Resolver or FormatOption.Package.
+	// It falls back to the full qualified name when the package is unknown.
+	ProtoEnumModeRelative
+	// ProtoEnumModeRelativeWithKind formats `PROTO` and `ENUM` type relative to its proto package with kind.
+	// e.g. `PROTO<Outer.Inner>`, `ENUM<Outer.InnerEnum>`
+	ProtoEnumModeRelativeWithKind
 )
 
 // ArrayMode controls how ARRAY types are rendered by selecting either base or
@@ -54,6 +66,17 @@ const (
 	ArrayModeRecursive
 )
 
+// AnnotationMode controls how type annotations, e.g. PostgreSQL types, are rendered.
+type AnnotationMode int
+
+const (
+	// AnnotationModeBase ignores type annotations. e.g. `NUMERIC` for PostgreSQL numeric
+	AnnotationModeBase AnnotationMode = iota
+	// AnnotationModeName formats annotated types as the annotation name. e.g. `PG_NUMERIC`, `PG_JSONB`, `PG_OID`
+	// Unknown annotations are ignored.
+	AnnotationModeName
+)
+
 // UnknownMode controls how unknown type codes are rendered by selecting
 // UNKNOWN, the raw numeric code, a verbose form, or panic behavior.
 type UnknownMode int
@@ -69,60 +92,91 @@ const (
 	UnknownModePanic
 )
 
+// DescriptorResolver looks up proto descriptors by full name.
+// *protoregistry.Files satisfies this interface.
+type DescriptorResolver interface {
+	FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error)
+}
+
 // FormatOption is an option for FormatType, and FormatStructFields.
 type FormatOption struct {
 	// Struct controls STRUCT formatting.
-	Struct  StructMode
+	Struct StructMode
 	// Proto controls PROTO formatting.
-	Proto   ProtoEnumMode
+	Proto ProtoEnumMode
 	// Enum controls ENUM formatting.
-	Enum    ProtoEnumMode
+	Enum ProtoEnumMode
 	// Array controls ARRAY formatting.
-	Array   ArrayMode
+	Array ArrayMode
 	// Unknown controls formatting for unknown type codes.
 	Unknown UnknownMode
+	// Annotation controls formatting of type annotations.
+	Annotation AnnotationMode
+	// Package is the proto package used by ProtoEnumModeRelative and ProtoEnumModeRelativeWithKind
+	// when Resolver is nil or doesn't know the type.
+	Package string
+	// Resolver resolves the proto package of PROTO and ENUM types for ProtoEnumModeRelative and ProtoEnumModeRelativeWithKind.
+	// FormatValue also uses it to decode PROTO and ENUM values.
+	Resolver DescriptorResolver
+	// ProtoValue controls PROTO value formatting in FormatValue.
+	ProtoValue ProtoValueMode
+	// EnumValue controls ENUM value formatting in FormatValue.
+	EnumValue EnumValueMode
+	// Strict makes TryFormatType and TryFormatStructFields return an error for malformed types reported by Validate.
+	// Other functions ignore it.
+	Strict bool
 }
 
 var (
 	// FormatOptionSimplest is a FormatOption for FormatTypeSimplest.
 	FormatOptionSimplest = FormatOption{
-		Struct:  StructModeBase,
-		Proto:   ProtoEnumModeBase,
-		Enum:    ProtoEnumModeBase,
-		Array:   ArrayModeBase,
-		Unknown: UnknownModeTypeCode,
+		Struct:     StructModeBase,
+		Proto:      ProtoEnumModeBase,
+		Enum:       ProtoEnumModeBase,
+		Array:      ArrayModeBase,
+		Unknown:    UnknownModeTypeCode,
+		ProtoValue: ProtoValueModeRaw,
+		EnumValue:  EnumValueModeRaw,
 	}
 	// FormatOptionSimple is a FormatOption for FormatTypeSimple.
 	FormatOptionSimple = FormatOption{
-		Struct:  StructModeBase,
-		Proto:   ProtoEnumModeLeaf,
-		Enum:    ProtoEnumModeLeaf,
-		Array:   ArrayModeRecursive,
-		Unknown: UnknownModeUnknown,
+		Struct:     StructModeBase,
+		Proto:      ProtoEnumModeLeaf,
+		Enum:       ProtoEnumModeLeaf,
+		Array:      ArrayModeRecursive,
+		Unknown:    UnknownModeUnknown,
+		ProtoValue: ProtoValueModeRaw,
+		EnumValue:  EnumValueModeRaw,
 	}
 	// FormatOptionNormal is a FormatOption for FormatTypeNormal.
 	FormatOptionNormal = FormatOption{
-		Struct:  StructModeRecursive,
-		Proto:   ProtoEnumModeLeaf,
-		Enum:    ProtoEnumModeLeaf,
-		Array:   ArrayModeRecursive,
-		Unknown: UnknownModeVerbose,
+		Struct:     StructModeRecursive,
+		Proto:      ProtoEnumModeLeaf,
+		Enum:       ProtoEnumModeLeaf,
+		Array:      ArrayModeRecursive,
+		Unknown:    UnknownModeVerbose,
+		ProtoValue: ProtoValueModeText,
+		EnumValue:  EnumValueModeName,
 	}
 	// FormatOptionVerbose is a FormatOption for FormatTypeVerbose.
 	FormatOptionVerbose = FormatOption{
-		Struct:  StructModeRecursiveWithName,
-		Proto:   ProtoEnumModeFull,
-		Enum:    ProtoEnumModeFull,
-		Array:   ArrayModeRecursive,
-		Unknown: UnknownModeVerbose,
+		Struct:     StructModeRecursiveWithName,
+		Proto:      ProtoEnumModeFull,
+		Enum:       ProtoEnumModeFull,
+		Array:      ArrayModeRecursive,
+		Unknown:    UnknownModeVerbose,
+		ProtoValue: ProtoValueModeText,
+		EnumValue:  EnumValueModeName,
 	}
 	// FormatOptionMoreVerbose is a FormatOption for FormatTypeMoreVerbose.
 	FormatOptionMoreVerbose = FormatOption{
-		Struct:  StructModeRecursiveWithName,
-		Proto:   ProtoEnumModeFullWithKind,
-		Enum:    ProtoEnumModeFullWithKind,
-		Array:   ArrayModeRecursive,
-		Unknown: UnknownModeVerbose,
+		Struct:     StructModeRecursiveWithName,
+		Proto:      ProtoEnumModeFullWithKind,
+		Enum:       ProtoEnumModeFullWithKind,
+		Array:      ArrayModeRecursive,
+		Unknown:    UnknownModeVerbose,
+		ProtoValue: ProtoValueModeText,
+		EnumValue:  EnumValueModeName,
 	}
 )
 
@@ -133,81 +187,291 @@ func lastCut(s, sep string) (before string, after string, found bool) {
 	return "", s, false
 }
 
+var (
+	// ErrUnknownTypeCode is returned when the type code is unknown and UnknownModePanic is used.
+	ErrUnknownTypeCode = errors.New("unknown TypeCode")
+	// ErrNotProtoEnum is returned when FormatProtoEnum is called with a type other than `PROTO` or `ENUM`.
+	ErrNotProtoEnum = errors.New("TypeCode must be PROTO or ENUM")
+)
+
+// mustFormat panics with the same message as before the error-returning functions were introduced.
+func mustFormat(s string, err error) string {
+	if err != nil {
+		panic(fmt.Sprintf("precondition failed: %v", err))
+	}
+	return s
+}
+
+func mustAppend(b []byte, err error) []byte {
+	if err != nil {
+		panic(fmt.Sprintf("precondition failed: %v", err))
+	}
+	return b
+}
+
+// bufPool is a pool of buffers used by the functions returning string or writing to io.Writer.
+var bufPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 256)
+		return &b
+	},
+}
+
+// formatWith formats into a pooled buffer using appendFn, and returns it as a string.
+func formatWith(appendFn func(dst []byte) ([]byte, error)) (string, error) {
+	bp := bufPool.Get().(*[]byte)
+	defer bufPool.Put(bp)
+
+	b, err := appendFn((*bp)[:0])
+	*bp = b
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// writeWith formats into a pooled buffer using appendFn, and writes it to w.
+// Nothing is written if formatting fails.
+func writeWith(w io.Writer, appendFn func(dst []byte) ([]byte, error)) (int, error) {
+	bp := bufPool.Get().(*[]byte)
+	defer bufPool.Put(bp)
+
+	b, err := appendFn((*bp)[:0])
+	*bp = b
+	if err != nil {
+		return 0, err
+	}
+	return w.Write(b)
+}
+
 // FormatType formats Cloud Spanner type using the given FormatOption.
+// It panics when UnknownModePanic is used and the type contains unknown type code. Use TryFormatType to get an error instead.
 func FormatType(typ *sppb.Type, opts FormatOption) string {
+	opts.Strict = false
+	return mustFormat(TryFormatType(typ, opts))
+}
+
+// TryFormatType is like FormatType, but it returns an error instead of panic.
+// If FormatOption.Strict is true, it also returns an error reported by Validate.
+func TryFormatType(typ *sppb.Type, opts FormatOption) (string, error) {
+	if opts.Strict {
+		if err := Validate(typ); err != nil {
+			return "", err
+		}
+	}
+	return formatWith(func(dst []byte) ([]byte, error) {
+		return appendType(dst, typ, opts)
+	})
+}
+
+// AppendType is like FormatType, but it appends the formatted type to dst and returns the extended buffer.
+func AppendType(dst []byte, typ *sppb.Type, opts FormatOption) []byte {
+	return mustAppend(appendType(dst, typ, opts))
+}
+
+// WriteType is like FormatType, but it writes the formatted type to w.
+// It returns an error instead of panic, and it writes nothing when formatting fails.
+// If FormatOption.Strict is true, it also returns an error reported by Validate.
+func WriteType(w io.Writer, typ *sppb.Type, opts FormatOption) (int, error) {
+	if opts.Strict {
+		if err := Validate(typ); err != nil {
+			return 0, err
+		}
+	}
+	return writeWith(w, func(dst []byte) ([]byte, error) {
+		return appendType(dst, typ, opts)
+	})
+}
+
+func appendType(dst []byte, typ *sppb.Type, opts FormatOption) ([]byte, error) {
 	code := typ.GetCode()
 	switch code {
 	case sppb.TypeCode_ARRAY:
 		if opts.Array == ArrayModeBase {
 			break
 		}
-		return fmt.Sprintf("ARRAY<%v>", FormatType(typ.GetArrayElementType(), opts))
+		dst = append(dst, "ARRAY<"...)
+		dst, err := appendType(dst, typ.GetArrayElementType(), opts)
+		if err != nil {
+			return dst, err
+		}
+		return append(dst, '>'), nil
 	case sppb.TypeCode_PROTO:
-		return FormatProtoEnum(typ, opts.Proto)
+		return appendProtoEnum(dst, typ, opts.Proto, opts)
 	case sppb.TypeCode_ENUM:
-		return FormatProtoEnum(typ, opts.Enum)
+		return appendProtoEnum(dst, typ, opts.Enum, opts)
 	case sppb.TypeCode_STRUCT:
 		if opts.Struct == StructModeBase {
 			break
 		}
-		return fmt.Sprintf("STRUCT<%v>", FormatStructFields(typ.GetStructType().GetFields(), opts))
+		dst = append(dst, "STRUCT<"...)
+		dst, err := appendStructFields(dst, typ.GetStructType().GetFields(), opts)
+		if err != nil {
+			return dst, err
+		}
+		return append(dst, '>'), nil
 	}
 
-	return FormatTypeCode(code, opts.Unknown)
+	if opts.Annotation == AnnotationModeName {
+		if annotation := typ.GetTypeAnnotation(); annotation != sppb.TypeAnnotationCode_TYPE_ANNOTATION_CODE_UNSPECIFIED {
+			if name, ok := sppb.TypeAnnotationCode_name[int32(annotation)]; ok {
+				return append(dst, name...), nil
+			}
+		}
+	}
+	return appendTypeCode(dst, code, opts.Unknown)
 }
 
 // FormatProtoEnum formats `PROTO` or `ENUM` type using ProtoEnumMode.
-// It panics when the input type is not `PROTO` or `ENUM`.
+// ProtoEnumModeRelative and ProtoEnumModeRelativeWithKind use the full qualified name
+// because no package is known; use FormatType with FormatOption.Package or FormatOption.Resolver instead.
+// It panics when the input type is not `PROTO` or `ENUM`. Use TryFormatProtoEnum to get an error instead.
 func FormatProtoEnum(typ *sppb.Type, mode ProtoEnumMode) string {
+	return mustFormat(TryFormatProtoEnum(typ, mode))
+}
+
+// TryFormatProtoEnum is like FormatProtoEnum, but it returns ErrNotProtoEnum instead of panic.
+func TryFormatProtoEnum(typ *sppb.Type, mode ProtoEnumMode) (string, error) {
+	return formatWith(func(dst []byte) ([]byte, error) {
+		return appendProtoEnum(dst, typ, mode, FormatOption{})
+	})
+}
+
+func appendProtoEnum(dst []byte, typ *sppb.Type, mode ProtoEnumMode, opts FormatOption) ([]byte, error) {
 	if typ.GetCode() != sppb.TypeCode_PROTO && typ.GetCode() != sppb.TypeCode_ENUM {
-		panic(fmt.Sprintf("precondition failed: TypeCode must be PROTO or ENUM, but %v", typ))
+		return dst, fmt.Errorf("%w, but %v", ErrNotProtoEnum, typ)
+	}
+
+	fqn := typ.GetProtoTypeFqn()
+	var name string
+	switch mode {
+	case ProtoEnumModeLeaf, ProtoEnumModeLeafWithKind:
+		_, name, _ = lastCut(fqn, ".")
+	case ProtoEnumModeFull, ProtoEnumModeFullWithKind:
+		name = fqn
+	case ProtoEnumModeRelative, ProtoEnumModeRelativeWithKind:
+		name = relativeName(fqn, opts)
+	default:
+		return append(dst, typ.GetCode().String()...), nil
 	}
 
 	switch mode {
-	case ProtoEnumModeLeaf:
-		_, after, _ := lastCut(typ.GetProtoTypeFqn(), ".")
-		return after
-	case ProtoEnumModeFull:
-		return typ.GetProtoTypeFqn()
-	case ProtoEnumModeLeafWithKind:
-		_, after, _ := lastCut(typ.GetProtoTypeFqn(), ".")
-		return fmt.Sprintf("%v<%v>", typ.GetCode().String(), after)
-	case ProtoEnumModeFullWithKind:
-		return fmt.Sprintf("%v<%v>", typ.GetCode().String(), typ.GetProtoTypeFqn())
+	case ProtoEnumModeLeafWithKind, ProtoEnumModeFullWithKind, ProtoEnumModeRelativeWithKind:
+		dst = append(dst, typ.GetCode().String()...)
+		dst = append(dst, '<')
+		dst = append(dst, name...)
+		return append(dst, '>'), nil
 	default:
-		return typ.GetCode().String()
+		return append(dst, name...), nil
 	}
 }
 
+// relativeName returns fqn relative to its proto package.
+// It returns fqn as is when the package is unknown or fqn is not in the package.
+func relativeName(fqn string, opts FormatOption) string {
+	pkg := opts.Package
+	if opts.Resolver != nil {
+		if desc, err := opts.Resolver.FindDescriptorByName(protoreflect.FullName(fqn)); err == nil {
+			pkg = string(desc.ParentFile().Package())
+		}
+	}
+	if pkg == "" {
+		return fqn
+	}
+	if name, ok := strings.CutPrefix(fqn, pkg+"."); ok {
+		return name
+	}
+	return fqn
+}
+
 // FormatTypeCode formats sppb.TypeCode, but it formats unknown type code as `UNKNOWN(int32(code))`. e.g. `UNKNOWN(-1)`
+// It panics when UnknownModePanic is used and the code is unknown. Use TryFormatTypeCode to get an error instead.
 func FormatTypeCode(code sppb.TypeCode, mode UnknownMode) string {
+	return mustFormat(TryFormatTypeCode(code, mode))
+}
+
+// TryFormatTypeCode is like FormatTypeCode, but it returns ErrUnknownTypeCode instead of panic.
+func TryFormatTypeCode(code sppb.TypeCode, mode UnknownMode) (string, error) {
 	if name, ok := sppb.TypeCode_name[int32(code)]; ok {
-		return name
+		return name, nil
+	}
+	return formatWith(func(dst []byte) ([]byte, error) {
+		return appendTypeCode(dst, code, mode)
+	})
+}
+
+func appendTypeCode(dst []byte, code sppb.TypeCode, mode UnknownMode) ([]byte, error) {
+	if name, ok := sppb.TypeCode_name[int32(code)]; ok {
+		return append(dst, name...), nil
 	}
 	switch mode {
 	case UnknownModeTypeCode:
-		return strconv.Itoa(int(code))
+		return strconv.AppendInt(dst, int64(code), 10), nil
 	case UnknownModeVerbose:
-		return fmt.Sprintf("UNKNOWN(%v)", int32(code))
+		dst = append(dst, "UNKNOWN("...)
+		dst = strconv.AppendInt(dst, int64(code), 10)
+		return append(dst, ')'), nil
 	case UnknownModePanic:
-		panic(fmt.Sprintf("precondition failed: unknown TypeCode(%v)", int32(code)))
+		return dst, fmt.Errorf("%w(%v)", ErrUnknownTypeCode, int32(code))
 	default:
-		return "UNKNOWN"
+		return append(dst, "UNKNOWN"...), nil
 	}
 }
 
 // FormatStructFields formats Cloud Spanner struct fields or `metadata.rowType` using the given FormatOption.
+// It panics in the same condition as FormatType. Use TryFormatStructFields to get an error instead.
 func FormatStructFields(fields []*sppb.StructType_Field, opts FormatOption) string {
-	var fieldsStr []string
-	for _, field := range fields {
-		typeStr := FormatType(field.GetType(), opts)
+	opts.Strict = false
+	return mustFormat(TryFormatStructFields(fields, opts))
+}
+
+// TryFormatStructFields is like FormatStructFields, but it returns an error instead of panic.
+// If FormatOption.Strict is true, it also returns an error reported by ValidateStructFields.
+func TryFormatStructFields(fields []*sppb.StructType_Field, opts FormatOption) (string, error) {
+	if opts.Strict {
+		if err := ValidateStructFields(fields, ValidateOption{}); err != nil {
+			return "", err
+		}
+	}
+	return formatWith(func(dst []byte) ([]byte, error) {
+		return appendStructFields(dst, fields, opts)
+	})
+}
+
+// AppendStructFields is like FormatStructFields, but it appends the formatted fields to dst and returns the extended buffer.
+func AppendStructFields(dst []byte, fields []*sppb.StructType_Field, opts FormatOption) []byte {
+	return mustAppend(appendStructFields(dst, fields, opts))
+}
+
+// WriteStructFields is like FormatStructFields, but it writes the formatted fields to w.
+// It returns an error instead of panic, and it writes nothing when formatting fails.
+// If FormatOption.Strict is true, it also returns an error reported by ValidateStructFields.
+func WriteStructFields(w io.Writer, fields []*sppb.StructType_Field, opts FormatOption) (int, error) {
+	if opts.Strict {
+		if err := ValidateStructFields(fields, ValidateOption{}); err != nil {
+			return 0, err
+		}
+	}
+	return writeWith(w, func(dst []byte) ([]byte, error) {
+		return appendStructFields(dst, fields, opts)
+	})
+}
+
+func appendStructFields(dst []byte, fields []*sppb.StructType_Field, opts FormatOption) ([]byte, error) {
+	for i, field := range fields {
+		if i > 0 {
+			dst = append(dst, ", "...)
+		}
 		if opts.Struct == StructModeRecursiveWithName && field.GetName() != "" {
-			fieldsStr = append(fieldsStr, fmt.Sprintf("%v %v", field.GetName(), typeStr))
-		} else {
-			fieldsStr = append(fieldsStr, typeStr)
+			dst = append(dst, field.GetName()...)
+			dst = append(dst, ' ')
+		}
+		var err error
+		if dst, err = appendType(dst, field.GetType(), opts); err != nil {
+			return dst, err
 		}
 	}
-	return strings.Join(fieldsStr, ", ")
+	return dst, nil
 }
 
 // FormatTypeSimplest formats Cloud Spanner type as simplest format.
@@ -1,14 +1,97 @@
 package spantype
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"strings"
 	"testing"
 
 	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
 
 	. "github.com/apstndb/spantype/typector"
 )
 
+// testFiles returns a registry containing the following definitions.
+//
+//	package examples.shop;
+//	message Book { string title = 1; Genre genre = 2; }
+//	message Outer { message Inner { int64 n = 1; } enum InnerEnum { INNER_ENUM_UNSPECIFIED = 0; } }
+//	enum Genre { GENRE_UNSPECIFIED = 0; FICTION = 1; }
+func testFiles(t testing.TB) *protoregistry.Files {
+	t.Helper()
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("examples/shop/book.proto"),
+		Package: proto.String("examples.shop"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Book"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:     proto.String("title"),
+						JsonName: proto.String("title"),
+						Number:   proto.Int32(1),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+					},
+					{
+						Name:     proto.String("genre"),
+						JsonName: proto.String("genre"),
+						Number:   proto.Int32(2),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_ENUM.Enum(),
+						TypeName: proto.String(".examples.shop.Genre"),
+					},
+				},
+			},
+			{
+				Name: proto.String("Outer"),
+				NestedType: []*descriptorpb.DescriptorProto{
+					{
+						Name: proto.String("Inner"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							{
+								Name:     proto.String("n"),
+								JsonName: proto.String("n"),
+								Number:   proto.Int32(1),
+								Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+								Type:     descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum(),
+							},
+						},
+					},
+				},
+				EnumType: []*descriptorpb.EnumDescriptorProto{
+					{
+						Name: proto.String("InnerEnum"),
+						Value: []*descriptorpb.EnumValueDescriptorProto{
+							{Name: proto.String("INNER_ENUM_UNSPECIFIED"), Number: proto.Int32(0)},
+						},
+					},
+				},
+			},
+		},
+		EnumType: []*descriptorpb.EnumDescriptorProto{
+			{
+				Name: proto.String("Genre"),
+				Value: []*descriptorpb.EnumValueDescriptorProto{
+					{Name: proto.String("GENRE_UNSPECIFIED"), Number: proto.Int32(0)},
+					{Name: proto.String("FICTION"), Number: proto.Int32(1)},
+				},
+			},
+		},
+	}
+	files, err := protodesc.NewFiles(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{fdp}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
 func TestFormatType(t *testing.T) {
 	for _, tt := range []struct {
 		desc            string
@@ -277,6 +360,66 @@ func TestFormatProtoEnum(t *testing.T) {
 	}
 }
 
+func TestFormatTypeRelative(t *testing.T) {
+	files := testFiles(t)
+	tests := []struct {
+		desc string
+		typ  *sppb.Type
+		opts FormatOption
+		want string
+	}{
+		{
+			desc: "nested PROTO with resolver",
+			typ:  FQNToProtoType("examples.shop.Outer.Inner"),
+			opts: FormatOption{Proto: ProtoEnumModeRelative, Resolver: files},
+			want: "Outer.Inner",
+		},
+		{
+			desc: "nested ENUM with resolver and kind",
+			typ:  FQNToEnumType("examples.shop.Outer.InnerEnum"),
+			opts: FormatOption{Enum: ProtoEnumModeRelativeWithKind, Resolver: files},
+			want: "ENUM<Outer.InnerEnum>",
+		},
+		{
+			desc: "resolver takes precedence over package",
+			typ:  FQNToProtoType("examples.shop.Book"),
+			opts: FormatOption{Proto: ProtoEnumModeRelative, Resolver: files, Package: "examples"},
+			want: "Book",
+		},
+		{
+			desc: "unresolved PROTO falls back to package",
+			typ:  FQNToProtoType("examples.Outer.Inner"),
+			opts: FormatOption{Proto: ProtoEnumModeRelative, Resolver: files, Package: "examples"},
+			want: "Outer.Inner",
+		},
+		{
+			desc: "PROTO outside of package",
+			typ:  FQNToProtoType("other.Outer.Inner"),
+			opts: FormatOption{Proto: ProtoEnumModeRelative, Package: "examples"},
+			want: "other.Outer.Inner",
+		},
+		{
+			desc: "unknown package",
+			typ:  FQNToProtoType("examples.Outer.Inner"),
+			opts: FormatOption{Proto: ProtoEnumModeRelativeWithKind},
+			want: "PROTO<examples.Outer.Inner>",
+		},
+		{
+			desc: "leaf drops enclosing message",
+			typ:  FQNToProtoType("examples.Outer.Inner"),
+			opts: FormatOption{Proto: ProtoEnumModeLeaf, Package: "examples"},
+			want: "Inner",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := FormatType(tt.typ, tt.opts); tt.want != got {
+				t.Errorf("FormatType want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
 func TestFormatTypeCode(t *testing.T) {
 	tests := []struct {
 		desc        string
@@ -308,3 +451,184 @@ func TestFormatTypeCode(t *testing.T) {
 		})
 	}
 }
+
+func TestTryFormatType(t *testing.T) {
+	tests := []struct {
+		desc    string
+		typ     *sppb.Type
+		opts    FormatOption
+		want    string
+		wantErr error
+	}{
+		{
+			desc: "best-effort malformed ARRAY",
+			typ:  &sppb.Type{Code: sppb.TypeCode_ARRAY},
+			opts: FormatOptionNormal,
+			want: "ARRAY<TYPE_CODE_UNSPECIFIED>",
+		},
+		{
+			desc:    "strict malformed ARRAY",
+			typ:     &sppb.Type{Code: sppb.TypeCode_ARRAY},
+			opts:    FormatOption{Array: ArrayModeRecursive, Strict: true},
+			wantErr: &ValidationError{},
+		},
+		{
+			desc:    "unknown type code with UnknownModePanic",
+			typ:     NameTypeToStructType("n", ElemTypeToArrayType(CodeToSimpleType(-1))),
+			opts:    FormatOption{Struct: StructModeRecursive, Array: ArrayModeRecursive, Unknown: UnknownModePanic},
+			wantErr: ErrUnknownTypeCode,
+		},
+		{
+			desc: "unknown type code is not visited",
+			typ:  NameTypeToStructType("n", ElemTypeToArrayType(CodeToSimpleType(-1))),
+			opts: FormatOption{Unknown: UnknownModePanic},
+			want: "STRUCT",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := TryFormatType(tt.typ, tt.opts)
+			switch want := tt.wantErr.(type) {
+			case nil:
+				if err != nil {
+					t.Errorf("TryFormatType failed: %v", err)
+				}
+			case *ValidationError:
+				if !errors.As(err, &want) {
+					t.Errorf("TryFormatType want *ValidationError, got: %v", err)
+				}
+			default:
+				if !errors.Is(err, want) {
+					t.Errorf("TryFormatType want: %v, got: %v", want, err)
+				}
+			}
+			if tt.want != got {
+				t.Errorf("TryFormatType want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := TryFormatProtoEnum(Int64(), ProtoEnumModeFull); !errors.Is(err, ErrNotProtoEnum) {
+		t.Errorf("TryFormatProtoEnum want: %v, got: %v", ErrNotProtoEnum, err)
+	}
+	if _, err := TryFormatStructFields([]*sppb.StructType_Field{{Name: "n"}}, FormatOption{Strict: true}); err == nil {
+		t.Errorf("TryFormatStructFields should fail for missing type")
+	}
+}
+
+func TestAppendType(t *testing.T) {
+	typ := NameTypeToStructType("arr", ElemTypeToArrayType(NameTypeToStructType("p", FQNToProtoType("examples.Book"))))
+	want := "prefix: STRUCT<arr ARRAY<STRUCT<p PROTO<examples.Book>>>>"
+	if got := string(AppendType([]byte("prefix: "), typ, FormatOptionMoreVerbose)); want != got {
+		t.Errorf("AppendType want: %v, got: %v", want, got)
+	}
+
+	want = "prefix: arr ARRAY<STRUCT<p PROTO<examples.Book>>>"
+	if got := string(AppendStructFields([]byte("prefix: "), typ.GetStructType().GetFields(), FormatOptionMoreVerbose)); want != got {
+		t.Errorf("AppendStructFields want: %v, got: %v", want, got)
+	}
+}
+
+func TestWriteType(t *testing.T) {
+	var sb strings.Builder
+	if _, err := WriteType(&sb, ElemCodeToArrayType(sppb.TypeCode_INT64), FormatOptionNormal); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "ARRAY<INT64>", sb.String(); want != got {
+		t.Errorf("WriteType want: %v, got: %v", want, got)
+	}
+
+	sb.Reset()
+	fields := []*sppb.StructType_Field{NameCodeToStructTypeField("a", sppb.TypeCode_INT64), NameCodeToStructTypeField("b", -1)}
+	if _, err := WriteStructFields(&sb, fields, FormatOption{Unknown: UnknownModePanic}); !errors.Is(err, ErrUnknownTypeCode) {
+		t.Errorf("WriteStructFields want: %v, got: %v", ErrUnknownTypeCode, err)
+	}
+	if sb.Len() != 0 {
+		t.Errorf("WriteStructFields should write nothing on error, got: %v", sb.String())
+	}
+}
+
+// wideFields returns a wide row type like `SELECT *` from a table with hundreds of columns.
+func wideFields(n int) []*sppb.StructType_Field {
+	var fields []*sppb.StructType_Field
+	for i := range n {
+		fields = append(fields,
+			NameTypeToStructTypeField(fmt.Sprintf("col%d", i), ElemTypeToArrayType(NameTypeToStructType("n", FQNToProtoType("examples.Book")))))
+	}
+	return fields
+}
+
+func BenchmarkFormatStructFields(b *testing.B) {
+	fields := wideFields(200)
+	b.ReportAllocs()
+	for range b.N {
+		FormatStructFields(fields, FormatOptionMoreVerbose)
+	}
+}
+
+func BenchmarkAppendStructFields(b *testing.B) {
+	fields := wideFields(200)
+	var buf []byte
+	b.ReportAllocs()
+	for range b.N {
+		buf = AppendStructFields(buf[:0], fields, FormatOptionMoreVerbose)
+	}
+}
+
+func BenchmarkWriteStructFields(b *testing.B) {
+	fields := wideFields(200)
+	b.ReportAllocs()
+	for range b.N {
+		if _, err := WriteStructFields(io.Discard, fields, FormatOptionMoreVerbose); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestFluentConstructors(t *testing.T) {
+	typ := Struct(F("id", Int64()), F("tags", Array(String())), F("", Proto("examples.Book")), F("g", Enum("examples.Genre")),
+		F("n", PGNumeric()), F("j", PGJSONB()), F("o", PGOID()))
+	want := MustNameTypeSlicesToStructType(
+		[]string{"id", "tags", "", "g", "n", "j", "o"},
+		[]*sppb.Type{
+			Int64(), ElemCodeToArrayType(sppb.TypeCode_STRING), FQNToProtoType("examples.Book"), FQNToEnumType("examples.Genre"),
+			{Code: sppb.TypeCode_NUMERIC, TypeAnnotation: sppb.TypeAnnotationCode_PG_NUMERIC},
+			{Code: sppb.TypeCode_JSON, TypeAnnotation: sppb.TypeAnnotationCode_PG_JSONB},
+			{Code: sppb.TypeCode_INT64, TypeAnnotation: sppb.TypeAnnotationCode_PG_OID},
+		})
+	if !proto.Equal(typ, want) {
+		t.Errorf("got %v, want %v", typ, want)
+	}
+}
+
+func TestFormatTypeAnnotation(t *testing.T) {
+	typ := Struct(
+		F("n", PGNumeric()),
+		F("j", PGJSONB()),
+		F("o", PGOID()),
+		F("ns", ElemCodeAnnotationToArrayType(sppb.TypeCode_NUMERIC, sppb.TypeAnnotationCode_PG_NUMERIC)),
+		NameCodeAnnotationToStructTypeField("unknown", sppb.TypeCode_INT64, sppb.TypeAnnotationCode(100)),
+		F("plain", Numeric()),
+	)
+	for _, tt := range []struct {
+		desc string
+		opts FormatOption
+		want string
+	}{
+		{"verbose", FormatOptionVerbose, "STRUCT<n NUMERIC, j JSON, o INT64, ns ARRAY<NUMERIC>, unknown INT64, plain NUMERIC>"},
+		{"more verbose", FormatOptionMoreVerbose, "STRUCT<n NUMERIC, j JSON, o INT64, ns ARRAY<NUMERIC>, unknown INT64, plain NUMERIC>"},
+		{"more verbose with annotation", func() FormatOption {
+			opts := FormatOptionMoreVerbose
+			opts.Annotation = AnnotationModeName
+			return opts
+		}(), "STRUCT<n PG_NUMERIC, j PG_JSONB, o PG_OID, ns ARRAY<PG_NUMERIC>, unknown INT64, plain NUMERIC>"},
+		{"normal with annotation", FormatOption{Struct: StructModeRecursive, Array: ArrayModeRecursive, Annotation: AnnotationModeName},
+			"STRUCT<PG_NUMERIC, PG_JSONB, PG_OID, ARRAY<PG_NUMERIC>, INT64, NUMERIC>"},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := FormatType(typ, tt.opts); got != tt.want {
+				t.Errorf("FormatType want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
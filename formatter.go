@@ -0,0 +1,123 @@
+package spantype
+
+import (
+	"container/list"
+	"sync"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+)
+
+// DefaultFormatterCacheSize is the cache size of Formatter used when NewFormatter is called with non-positive size.
+const DefaultFormatterCacheSize = 1024
+
+// Formatter formats types using a fixed FormatOption, and caches the results keyed by the canonical encoding of the types.
+// See AppendCanonical for the canonical encoding.
+// The cache is bounded, and the least recently used entry is evicted when it is full.
+// It assumes FormatOption.Resolver is not modified after NewFormatter.
+// It is safe for concurrent use.
+type Formatter struct {
+	opts  FormatOption
+	cache *lruCache
+}
+
+// NewFormatter returns a Formatter using the given FormatOption which caches up to size results.
+func NewFormatter(opts FormatOption, size int) *Formatter {
+	if size <= 0 {
+		size = DefaultFormatterCacheSize
+	}
+	return &Formatter{opts: opts, cache: newLRUCache(size)}
+}
+
+// FormatType is like FormatType, but the result is cached.
+func (f *Formatter) FormatType(typ *sppb.Type) string {
+	return f.format(func(dst []byte) []byte {
+		return appendCanonicalType(append(dst, 't'), typ, f.fingerprintOption())
+	}, func() string {
+		return FormatType(typ, f.opts)
+	})
+}
+
+// FormatStructFields is like FormatStructFields, but the result is cached.
+func (f *Formatter) FormatStructFields(fields []*sppb.StructType_Field) string {
+	return f.format(func(dst []byte) []byte {
+		return appendCanonicalFields(append(dst, 'f'), fields, f.fingerprintOption())
+	}, func() string {
+		return FormatStructFields(fields, f.opts)
+	})
+}
+
+// fingerprintOption ignores what FormatOption doesn't format so that more types share cache entries.
+func (f *Formatter) fingerprintOption() FingerprintOption {
+	return FingerprintOption{
+		IgnoreFieldNames:  f.opts.Struct != StructModeRecursiveWithName,
+		IgnoreAnnotations: f.opts.Annotation == AnnotationModeBase,
+	}
+}
+
+func (f *Formatter) format(appendKey func(dst []byte) []byte, format func() string) string {
+	bp := bufPool.Get().(*[]byte)
+	defer bufPool.Put(bp)
+
+	key := appendKey((*bp)[:0])
+	*bp = key
+	if s, ok := f.cache.get(key); ok {
+		return s
+	}
+
+	s := format()
+	f.cache.add(string(key), s)
+	return s
+}
+
+// lruCache is a concurrency-safe LRU cache of strings.
+type lruCache struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type lruEntry struct {
+	key, value string
+}
+
+func newLRUCache(size int) *lruCache {
+	return &lruCache{size: size, ll: list.New(), items: make(map[string]*list.Element, size)}
+}
+
+func (c *lruCache) get(key []byte) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// The conversion doesn't allocate in map index expressions.
+	e, ok := c.items[string(key)]
+	if !ok {
+		return "", false
+	}
+	c.ll.MoveToFront(e)
+	return e.Value.(*lruEntry).value, true
+}
+
+func (c *lruCache) add(key, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		e.Value.(*lruEntry).value = value
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&lruEntry{key: key, value: value})
+	if c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry).key)
+	}
+}
+
+func (c *lruCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
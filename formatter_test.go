@@ -0,0 +1,106 @@
+package spantype
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	. "github.com/apstndb/spantype/typector"
+)
+
+func TestFormatter(t *testing.T) {
+	f := NewFormatter(FormatOptionVerbose, 2)
+
+	typ := NameTypeToStructType("arr", ElemCodeToArrayType(sppb.TypeCode_INT64))
+	want := "STRUCT<arr ARRAY<INT64>>"
+	for range 2 {
+		if got := f.FormatType(typ); want != got {
+			t.Errorf("Formatter.FormatType want: %v, got: %v", want, got)
+		}
+	}
+	// An equivalent but distinct value hits the cache.
+	if got := f.FormatType(NameTypeToStructType("arr", ElemCodeToArrayType(sppb.TypeCode_INT64))); want != got {
+		t.Errorf("Formatter.FormatType want: %v, got: %v", want, got)
+	}
+	if n := f.cache.len(); n != 1 {
+		t.Errorf("cache should have 1 entry, got: %v", n)
+	}
+
+	// STRUCT type and its fields are different entries.
+	if want, got := "arr ARRAY<INT64>", f.FormatStructFields(typ.GetStructType().GetFields()); want != got {
+		t.Errorf("Formatter.FormatStructFields want: %v, got: %v", want, got)
+	}
+	if n := f.cache.len(); n != 2 {
+		t.Errorf("cache should have 2 entries, got: %v", n)
+	}
+
+	// The cache is bounded.
+	for _, typ := range []*sppb.Type{Int64(), String(), Bool()} {
+		if want, got := FormatTypeVerbose(typ), f.FormatType(typ); want != got {
+			t.Errorf("Formatter.FormatType want: %v, got: %v", want, got)
+		}
+	}
+	if n := f.cache.len(); n != 2 {
+		t.Errorf("cache should have 2 entries, got: %v", n)
+	}
+}
+
+func TestFormatterAnnotation(t *testing.T) {
+	opts := FormatOptionMoreVerbose
+	opts.Annotation = AnnotationModeName
+	f := NewFormatter(opts, 2)
+	if want, got := "NUMERIC", f.FormatType(Numeric()); want != got {
+		t.Errorf("FormatType want: %v, got: %v", want, got)
+	}
+	if want, got := "PG_NUMERIC", f.FormatType(PGNumeric()); want != got {
+		t.Errorf("FormatType want: %v, got: %v", want, got)
+	}
+}
+
+func TestFormatterConcurrent(t *testing.T) {
+	f := NewFormatter(FormatOptionNormal, 8)
+
+	var wg sync.WaitGroup
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 100 {
+				typ := NameTypeToStructType(fmt.Sprint(j%10), ElemTypeToArrayType(CodeToSimpleType(sppb.TypeCode(i%4+1))))
+				if want, got := FormatTypeNormal(typ), f.FormatType(typ); want != got {
+					t.Errorf("Formatter.FormatType want: %v, got: %v", want, got)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkFormatter(b *testing.B) {
+	fields := wideFields(200)
+
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			FormatStructFields(fields, FormatOptionMoreVerbose)
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		f := NewFormatter(FormatOptionMoreVerbose, 0)
+		b.ReportAllocs()
+		for range b.N {
+			f.FormatStructFields(fields)
+		}
+	})
+	b.Run("cached parallel", func(b *testing.B) {
+		f := NewFormatter(FormatOptionMoreVerbose, 0)
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				f.FormatStructFields(fields)
+			}
+		})
+	})
+}
@@ -0,0 +1,246 @@
+package spantype
+
+import (
+	"go/format"
+	"strconv"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+)
+
+// GoSyntaxOption is an option for FormatGoSyntax and FormatStructFieldsGoSyntax.
+type GoSyntaxOption struct {
+	// TypectorName is the package name of typector. `.` means dot import. If it is empty, `typector` is used.
+	TypectorName string
+	// SpannerpbName is the package name of spannerpb. `.` means dot import. If it is empty, `sppb` is used.
+	SpannerpbName string
+	// Multiline puts each struct field on its own line.
+	Multiline bool
+}
+
+func (o GoSyntaxOption) internal() goSyntaxOption {
+	return goSyntaxOption{
+		typector:  qualifier(o.TypectorName, "typector"),
+		sppb:      qualifier(o.SpannerpbName, "sppb"),
+		multiline: o.Multiline,
+	}
+}
+
+func qualifier(name, defaultName string) string {
+	switch name {
+	case "":
+		return defaultName + "."
+	case ".":
+		return ""
+	default:
+		return name + "."
+	}
+}
+
+// goSyntaxOption is GoSyntaxOption with resolved qualifiers.
+type goSyntaxOption struct {
+	// typector is the qualifier of typector package including `.`, or empty for dot import.
+	typector string
+	// sppb is the qualifier of spannerpb package including `.`, or empty for dot import.
+	sppb string
+	// multiline puts each struct field on its own line.
+	multiline bool
+}
+
+// FormatGoSyntax formats the type as a gofmt-formatted Go expression which constructs the type using typector.
+// e.g. `typector.NameTypeToStructType("n", typector.ElemTypeToArrayType(typector.Int64()))`
+// Types which typector can't construct, e.g. malformed types, are formatted as composite literals.
+func FormatGoSyntax(typ *sppb.Type, opts GoSyntaxOption) string {
+	return gofmt(appendGoSyntax(nil, typ, opts.internal()))
+}
+
+// FormatStructFieldsGoSyntax is like FormatGoSyntax, but it formats struct fields or `metadata.rowType` as
+// a Go expression of `[]*sppb.StructType_Field`.
+func FormatStructFieldsGoSyntax(fields []*sppb.StructType_Field, opts GoSyntaxOption) string {
+	return gofmt(appendGoSyntaxFields(nil, fields, opts.internal()))
+}
+
+// gofmt formats a Go expression. It returns the input as is if it fails.
+func gofmt(src []byte) string {
+	formatted, err := format.Source(src)
+	if err != nil {
+		return string(src)
+	}
+	return string(formatted)
+}
+
+// typectorShorthands is the names of typector shorthand constructors.
+var typectorShorthands = map[sppb.TypeCode]string{
+	sppb.TypeCode_BOOL:      "Bool",
+	sppb.TypeCode_INT64:     "Int64",
+	sppb.TypeCode_FLOAT32:   "Float32",
+	sppb.TypeCode_FLOAT64:   "Float64",
+	sppb.TypeCode_TIMESTAMP: "Timestamp",
+	sppb.TypeCode_DATE:      "Date",
+	sppb.TypeCode_STRING:    "String",
+	sppb.TypeCode_BYTES:     "Bytes",
+	sppb.TypeCode_NUMERIC:   "Numeric",
+	sppb.TypeCode_JSON:      "JSON",
+	sppb.TypeCode_INTERVAL:  "Interval",
+	sppb.TypeCode_UUID:      "UUID",
+}
+
+// typectorAnnotatedShorthands is the names of typector shorthand constructors for annotated types.
+var typectorAnnotatedShorthands = map[[2]int32]string{
+	{int32(sppb.TypeCode_NUMERIC), int32(sppb.TypeAnnotationCode_PG_NUMERIC)}: "PGNumeric",
+	{int32(sppb.TypeCode_JSON), int32(sppb.TypeAnnotationCode_PG_JSONB)}:      "PGJSONB",
+	{int32(sppb.TypeCode_INT64), int32(sppb.TypeAnnotationCode_PG_OID)}:       "PGOID",
+}
+
+// appendGoSyntax appends a Go expression which constructs the type using typector.
+// Types which typector can't construct are rendered as composite literals.
+func appendGoSyntax(dst []byte, typ *sppb.Type, opts goSyntaxOption) []byte {
+	if typ == nil {
+		return append(dst, "nil"...)
+	}
+	if isAnnotatedSimpleType(typ) {
+		if name, ok := typectorAnnotatedShorthands[[2]int32{int32(typ.GetCode()), int32(typ.GetTypeAnnotation())}]; ok {
+			return append(dst, opts.typector+name+"()"...)
+		}
+		if _, ok := sppb.TypeAnnotationCode_name[int32(typ.GetTypeAnnotation())]; ok {
+			dst = append(dst, opts.typector+"CodeAnnotationToSimpleType("...)
+			dst = appendGoSyntaxTypeCode(dst, typ.GetCode(), opts)
+			return append(dst, ", "+opts.sppb+"TypeAnnotationCode_"+typ.GetTypeAnnotation().String()+")"...)
+		}
+	}
+	if !isTypectorConstructible(typ) {
+		return appendGoSyntaxLiteral(dst, typ, opts)
+	}
+
+	switch code := typ.GetCode(); code {
+	case sppb.TypeCode_ARRAY:
+		dst = append(dst, opts.typector+"ElemTypeToArrayType("...)
+		dst = appendGoSyntax(dst, typ.GetArrayElementType(), opts)
+		return append(dst, ')')
+	case sppb.TypeCode_PROTO:
+		dst = append(dst, opts.typector+"FQNToProtoType("...)
+		dst = strconv.AppendQuote(dst, typ.GetProtoTypeFqn())
+		return append(dst, ')')
+	case sppb.TypeCode_ENUM:
+		dst = append(dst, opts.typector+"FQNToEnumType("...)
+		dst = strconv.AppendQuote(dst, typ.GetProtoTypeFqn())
+		return append(dst, ')')
+	case sppb.TypeCode_STRUCT:
+		fields := typ.GetStructType().GetFields()
+		if len(fields) == 1 {
+			dst = append(dst, opts.typector+"NameTypeToStructType("...)
+			dst = strconv.AppendQuote(dst, fields[0].GetName())
+			dst = append(dst, ", "...)
+			dst = appendGoSyntax(dst, fields[0].GetType(), opts)
+			return append(dst, ')')
+		}
+		dst = append(dst, opts.typector+"StructTypeFieldsToStructType("...)
+		dst = appendGoSyntaxFields(dst, fields, opts)
+		return append(dst, ')')
+	default:
+		if name, ok := typectorShorthands[code]; ok {
+			return append(dst, opts.typector+name+"()"...)
+		}
+		dst = append(dst, opts.typector+"CodeToSimpleType("...)
+		dst = appendGoSyntaxTypeCode(dst, code, opts)
+		return append(dst, ')')
+	}
+}
+
+// appendGoSyntaxFields appends a Go expression of []*sppb.StructType_Field.
+func appendGoSyntaxFields(dst []byte, fields []*sppb.StructType_Field, opts goSyntaxOption) []byte {
+	dst = append(dst, "[]*"+opts.sppb+"StructType_Field{"...)
+	if opts.multiline && len(fields) > 0 {
+		dst = append(dst, '\n')
+	}
+	for i, field := range fields {
+		if i > 0 && !opts.multiline {
+			dst = append(dst, ", "...)
+		}
+		switch {
+		case field == nil:
+			dst = append(dst, "nil"...)
+		case field.GetName() == "":
+			dst = append(dst, opts.typector+"TypeToUnnamedStructTypeField("...)
+			dst = appendGoSyntax(dst, field.GetType(), opts)
+			dst = append(dst, ')')
+		default:
+			dst = append(dst, opts.typector+"NameTypeToStructTypeField("...)
+			dst = strconv.AppendQuote(dst, field.GetName())
+			dst = append(dst, ", "...)
+			dst = appendGoSyntax(dst, field.GetType(), opts)
+			dst = append(dst, ')')
+		}
+		if opts.multiline {
+			dst = append(dst, ",\n"...)
+		}
+	}
+	return append(dst, '}')
+}
+
+// isTypectorConstructible reports whether typector constructors can construct the type itself.
+// Children are checked separately.
+func isTypectorConstructible(typ *sppb.Type) bool {
+	if typ.GetTypeAnnotation() != sppb.TypeAnnotationCode_TYPE_ANNOTATION_CODE_UNSPECIFIED {
+		return false
+	}
+
+	switch typ.GetCode() {
+	case sppb.TypeCode_ARRAY:
+		return typ.GetArrayElementType() != nil && typ.GetStructType() == nil && typ.GetProtoTypeFqn() == ""
+	case sppb.TypeCode_STRUCT:
+		return typ.GetStructType() != nil && typ.GetArrayElementType() == nil && typ.GetProtoTypeFqn() == ""
+	case sppb.TypeCode_PROTO, sppb.TypeCode_ENUM:
+		return typ.GetArrayElementType() == nil && typ.GetStructType() == nil
+	default:
+		return typ.GetArrayElementType() == nil && typ.GetStructType() == nil && typ.GetProtoTypeFqn() == ""
+	}
+}
+
+// isAnnotatedSimpleType reports whether the type is a scalar type with a type annotation.
+func isAnnotatedSimpleType(typ *sppb.Type) bool {
+	switch typ.GetCode() {
+	case sppb.TypeCode_ARRAY, sppb.TypeCode_STRUCT, sppb.TypeCode_PROTO, sppb.TypeCode_ENUM:
+		return false
+	}
+	return typ.GetTypeAnnotation() != sppb.TypeAnnotationCode_TYPE_ANNOTATION_CODE_UNSPECIFIED &&
+		typ.GetArrayElementType() == nil && typ.GetStructType() == nil && typ.GetProtoTypeFqn() == ""
+}
+
+// appendGoSyntaxLiteral appends a composite literal of *sppb.Type.
+func appendGoSyntaxLiteral(dst []byte, typ *sppb.Type, opts goSyntaxOption) []byte {
+	dst = append(dst, "&"+opts.sppb+"Type{Code: "...)
+	dst = appendGoSyntaxTypeCode(dst, typ.GetCode(), opts)
+	if elem := typ.GetArrayElementType(); elem != nil {
+		dst = append(dst, ", ArrayElementType: "...)
+		dst = appendGoSyntax(dst, elem, opts)
+	}
+	if structType := typ.GetStructType(); structType != nil {
+		dst = append(dst, ", StructType: &"+opts.sppb+"StructType{Fields: "...)
+		dst = appendGoSyntaxFields(dst, structType.GetFields(), opts)
+		dst = append(dst, '}')
+	}
+	if fqn := typ.GetProtoTypeFqn(); fqn != "" {
+		dst = append(dst, ", ProtoTypeFqn: "...)
+		dst = strconv.AppendQuote(dst, fqn)
+	}
+	if annotation := typ.GetTypeAnnotation(); annotation != sppb.TypeAnnotationCode_TYPE_ANNOTATION_CODE_UNSPECIFIED {
+		dst = append(dst, ", TypeAnnotation: "...)
+		if _, ok := sppb.TypeAnnotationCode_name[int32(annotation)]; ok {
+			dst = append(dst, opts.sppb+"TypeAnnotationCode_"+annotation.String()...)
+		} else {
+			dst = append(dst, opts.sppb+"TypeAnnotationCode("...)
+			dst = strconv.AppendInt(dst, int64(annotation), 10)
+			dst = append(dst, ')')
+		}
+	}
+	return append(dst, '}')
+}
+
+func appendGoSyntaxTypeCode(dst []byte, code sppb.TypeCode, opts goSyntaxOption) []byte {
+	if _, ok := sppb.TypeCode_name[int32(code)]; ok {
+		return append(dst, opts.sppb+"TypeCode_"+code.String()...)
+	}
+	dst = append(dst, opts.sppb+"TypeCode("...)
+	dst = strconv.AppendInt(dst, int64(code), 10)
+	return append(dst, ')')
+}
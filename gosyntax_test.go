@@ -0,0 +1,56 @@
+package spantype
+
+import (
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	. "github.com/apstndb/spantype/typector"
+)
+
+func TestFormatGoSyntax(t *testing.T) {
+	for _, tt := range []struct {
+		desc string
+		typ  *sppb.Type
+		opts GoSyntaxOption
+		want string
+	}{
+		{"default qualifiers", NameTypeToStructType("n", ElemTypeToArrayType(Int64())), GoSyntaxOption{},
+			`typector.NameTypeToStructType("n", typector.ElemTypeToArrayType(typector.Int64()))`},
+		{"dot import", NameTypeToStructType("n", ElemTypeToArrayType(Int64())), GoSyntaxOption{TypectorName: "."},
+			`NameTypeToStructType("n", ElemTypeToArrayType(Int64()))`},
+		{"custom qualifiers", &sppb.Type{Code: sppb.TypeCode_ARRAY, TypeAnnotation: sppb.TypeAnnotationCode_PG_NUMERIC},
+			GoSyntaxOption{SpannerpbName: "spannerpb"},
+			`&spannerpb.Type{Code: spannerpb.TypeCode_ARRAY, TypeAnnotation: spannerpb.TypeAnnotationCode_PG_NUMERIC}`},
+		{"PG shorthands", Struct(F("n", PGNumeric()), F("j", PGJSONB()), F("o", PGOID())), GoSyntaxOption{TypectorName: "."},
+			`StructTypeFieldsToStructType([]*sppb.StructType_Field{NameTypeToStructTypeField("n", PGNumeric()), NameTypeToStructTypeField("j", PGJSONB()), NameTypeToStructTypeField("o", PGOID())})`},
+		{"annotation without shorthand", CodeAnnotationToSimpleType(sppb.TypeCode_STRING, sppb.TypeAnnotationCode_PG_JSONB), GoSyntaxOption{TypectorName: "."},
+			`CodeAnnotationToSimpleType(sppb.TypeCode_STRING, sppb.TypeAnnotationCode_PG_JSONB)`},
+		{"multiline", MustNameTypeSlicesToStructType([]string{"a", ""}, []*sppb.Type{Int64(), FQNToEnumType("examples.Genre")}),
+			GoSyntaxOption{TypectorName: ".", Multiline: true},
+			"StructTypeFieldsToStructType([]*sppb.StructType_Field{\n" +
+				"\tNameTypeToStructTypeField(\"a\", Int64()),\n" +
+				"\tTypeToUnnamedStructTypeField(FQNToEnumType(\"examples.Genre\")),\n" +
+				"})"},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := FormatGoSyntax(tt.typ, tt.opts); got != tt.want {
+				t.Errorf("FormatGoSyntax want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFormatStructFieldsGoSyntax(t *testing.T) {
+	fields := []*sppb.StructType_Field{
+		NameCodeToStructTypeField("id", sppb.TypeCode_INT64),
+		NameTypeToStructTypeField("tags", ElemCodeToArrayType(sppb.TypeCode_STRING)),
+	}
+	want := "[]*sppb.StructType_Field{\n" +
+		"\ttypector.NameTypeToStructTypeField(\"id\", typector.Int64()),\n" +
+		"\ttypector.NameTypeToStructTypeField(\"tags\", typector.ElemTypeToArrayType(typector.String())),\n" +
+		"}"
+	if got := FormatStructFieldsGoSyntax(fields, GoSyntaxOption{Multiline: true}); got != want {
+		t.Errorf("FormatStructFieldsGoSyntax want: %v, got: %v", want, got)
+	}
+}
@@ -0,0 +1,253 @@
+// Package interceptor provides gRPC client interceptors which annotate Cloud Spanner
+// ExecuteSql and ExecuteStreamingSql calls with formatted param types and row types.
+//
+// Use them with the Go client, e.g.
+//
+//	spanner.NewClientWithConfig(ctx, database, spanner.ClientConfig{}, option.WithGRPCDialOption(
+//		grpc.WithChainUnaryInterceptor(interceptor.UnaryClientInterceptor(opts)),
+//	), option.WithGRPCDialOption(
+//		grpc.WithChainStreamInterceptor(interceptor.StreamClientInterceptor(opts)),
+//	))
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	executeSQLMethod          = "/google.spanner.v1.Spanner/ExecuteSql"
+	executeStreamingSQLMethod = "/google.spanner.v1.Spanner/ExecuteStreamingSql"
+)
+
+// ErrorInfoDomain is the domain of the ErrorInfo detail added to annotated errors.
+// Its metadata has `param_types` and `row_type` keys when they are known.
+const ErrorInfoDomain = "spantype.apstndb.github.com"
+
+// ErrorInfoReason is the reason of the ErrorInfo detail added to annotated errors.
+const ErrorInfoReason = "SPANNER_TYPES"
+
+// Options is an option for UnaryClientInterceptor and StreamClientInterceptor.
+type Options struct {
+	// Logger logs formatted types when each call finishes. If nil, nothing is logged.
+	Logger *slog.Logger
+	// Level is the log level. The zero value is slog.LevelInfo.
+	Level slog.Level
+	// AnnotateErrors makes failed calls return *Error, which keeps the status code and details
+	// and adds formatted types to the message and an ErrorInfo detail.
+	AnnotateErrors bool
+	// FormatOption formats types. If nil, spantype.FormatOptionMoreVerbose is used as in spantype.FormatTypeMoreVerbose.
+	FormatOption *spantype.FormatOption
+}
+
+func (o Options) formatOption() spantype.FormatOption {
+	if o.FormatOption == nil {
+		return spantype.FormatOptionMoreVerbose
+	}
+	return *o.FormatOption
+}
+
+// UnaryClientInterceptor returns an interceptor for ExecuteSql. Other methods are passed through.
+func UnaryClientInterceptor(opts Options) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		if method != executeSQLMethod {
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		}
+
+		err := invoker(ctx, method, req, reply, cc, callOpts...)
+		var metadata *sppb.ResultSetMetadata
+		if resultSet, ok := reply.(*sppb.ResultSet); ok && err == nil {
+			metadata = resultSet.GetMetadata()
+		}
+		return opts.finish(ctx, method, paramTypesOf(req), metadata, err)
+	}
+}
+
+// StreamClientInterceptor returns an interceptor for ExecuteStreamingSql. Other methods are passed through.
+// The metadata is taken from the first PartialResultSet.
+func StreamClientInterceptor(opts Options) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if method != executeStreamingSQLMethod {
+			return streamer(ctx, desc, cc, method, callOpts...)
+		}
+
+		stream, err := streamer(ctx, desc, cc, method, callOpts...)
+		if err != nil {
+			return nil, opts.finish(ctx, method, nil, nil, err)
+		}
+		return &clientStream{ClientStream: stream, ctx: ctx, method: method, opts: opts}, nil
+	}
+}
+
+// clientStream captures param types of the request and the metadata of the first response.
+type clientStream struct {
+	grpc.ClientStream
+	ctx    context.Context
+	method string
+	opts   Options
+
+	mu         sync.Mutex
+	paramTypes map[string]*sppb.Type
+	metadata   *sppb.ResultSetMetadata
+	finished   bool
+}
+
+func (s *clientStream) SendMsg(m any) error {
+	s.mu.Lock()
+	if paramTypes := paramTypesOf(m); paramTypes != nil {
+		s.paramTypes = paramTypes
+	}
+	s.mu.Unlock()
+	return s.ClientStream.SendMsg(m)
+}
+
+func (s *clientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if partialResultSet, ok := m.(*sppb.PartialResultSet); ok && err == nil && s.metadata == nil {
+		s.metadata = partialResultSet.GetMetadata()
+	}
+	switch {
+	case err == nil, s.finished:
+		return err
+	case errors.Is(err, io.EOF):
+		s.finished = true
+		s.opts.finish(s.ctx, s.method, s.paramTypes, s.metadata, nil)
+		return err
+	default:
+		s.finished = true
+		return s.opts.finish(s.ctx, s.method, s.paramTypes, s.metadata, err)
+	}
+}
+
+func paramTypesOf(req any) map[string]*sppb.Type {
+	if req, ok := req.(*sppb.ExecuteSqlRequest); ok {
+		return req.GetParamTypes()
+	}
+	return nil
+}
+
+// finish logs the types, and returns the error annotated if Options.AnnotateErrors is true.
+func (o Options) finish(ctx context.Context, method string, paramTypes map[string]*sppb.Type, metadata *sppb.ResultSetMetadata, err error) error {
+	if o.Logger == nil && (err == nil || !o.AnnotateErrors) {
+		return err
+	}
+
+	formatOpt := o.formatOption()
+	params := spantype.FormatParams(nil, paramTypes, formatOpt)
+	var rowType string
+	if metadata != nil {
+		rowType, _ = spantype.TryFormatStructFields(metadata.GetRowType().GetFields(), formatOpt)
+	}
+
+	if o.Logger != nil && o.Logger.Enabled(ctx, o.Level) {
+		attrs := []slog.Attr{slog.String("method", method)}
+		if params != "" {
+			attrs = append(attrs, slog.String("param_types", params))
+		}
+		if metadata != nil {
+			attrs = append(attrs, slog.String("row_type", rowType))
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		o.Logger.LogAttrs(ctx, o.Level, "spanner types", attrs...)
+	}
+
+	if err == nil || !o.AnnotateErrors {
+		return err
+	}
+	return newError(err, params, rowType, metadata != nil)
+}
+
+// Error is an error of ExecuteSql or ExecuteStreamingSql annotated with formatted types.
+// It keeps the gRPC status code and details of Err.
+type Error struct {
+	// Err is the original error.
+	Err error
+	// ParamTypes is the formatted param types. e.g. `@id INT64, @tags ARRAY<STRING>`
+	ParamTypes string
+	// RowType is the formatted row type. e.g. `n INT64, s STRING`
+	RowType string
+	// HasRowType reports whether the metadata is received. RowType is empty for empty row types.
+	HasRowType bool
+}
+
+// AnnotateError annotates the error with param types and the row type, e.g. a decoding error of
+// spanner.RowIterator with RowIterator.Metadata. It returns err as is if err is nil or there are no types.
+func AnnotateError(err error, paramTypes map[string]*sppb.Type, metadata *sppb.ResultSetMetadata, opts spantype.FormatOption) error {
+	if err == nil {
+		return nil
+	}
+	var rowType string
+	if metadata != nil {
+		rowType, _ = spantype.TryFormatStructFields(metadata.GetRowType().GetFields(), opts)
+	}
+	return newError(err, spantype.FormatParams(nil, paramTypes, opts), rowType, metadata != nil)
+}
+
+// newError returns *Error, or err as is if there are no types.
+func newError(err error, paramTypes, rowType string, hasRowType bool) error {
+	if paramTypes == "" && !hasRowType {
+		return err
+	}
+	return &Error{Err: err, ParamTypes: paramTypes, RowType: rowType, HasRowType: hasRowType}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error() + " " + e.annotation()
+}
+
+// annotation returns e.g. `(param types: @id INT64; row type: n INT64)`.
+func (e *Error) annotation() string {
+	var parts []string
+	if e.ParamTypes != "" {
+		parts = append(parts, "param types: "+e.ParamTypes)
+	}
+	if e.HasRowType {
+		parts = append(parts, "row type: "+e.RowType)
+	}
+	return "(" + strings.Join(parts, "; ") + ")"
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// GRPCStatus returns the status of Err with the annotated message and an ErrorInfo detail.
+// It allows status.FromError and status.Code to see the original code through Error.
+func (e *Error) GRPCStatus() *status.Status {
+	p := status.Convert(e.Err).Proto()
+	p.Message = fmt.Sprintf("%v %v", p.GetMessage(), e.annotation())
+
+	info := &errdetails.ErrorInfo{
+		Reason:   ErrorInfoReason,
+		Domain:   ErrorInfoDomain,
+		Metadata: map[string]string{},
+	}
+	if e.ParamTypes != "" {
+		info.Metadata["param_types"] = e.ParamTypes
+	}
+	if e.HasRowType {
+		info.Metadata["row_type"] = e.RowType
+	}
+	if detail, err := anypb.New(info); err == nil {
+		p.Details = append(slices.Clone(p.GetDetails()), detail)
+	}
+	return status.FromProto(p)
+}
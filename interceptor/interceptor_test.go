@@ -0,0 +1,242 @@
+package interceptor_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spantype"
+	. "github.com/apstndb/spantype/interceptor"
+	. "github.com/apstndb/spantype/typector"
+)
+
+// fakeSpanner returns the row type `n INT64, s ARRAY<PROTO<examples.Book>>`.
+// It fails with InvalidArgument when the SQL is "error", after sending the metadata for streaming calls.
+type fakeSpanner struct {
+	sppb.UnimplementedSpannerServer
+}
+
+var fakeMetadata = &sppb.ResultSetMetadata{
+	RowType: &sppb.StructType{Fields: []*sppb.StructType_Field{
+		F("n", Int64()),
+		F("s", Array(Proto("examples.Book"))),
+	}},
+}
+
+var fakeError = status.Error(codes.InvalidArgument, "Value has type STRING which cannot be inserted into column n, which has type INT64")
+
+func (*fakeSpanner) ExecuteSql(ctx context.Context, req *sppb.ExecuteSqlRequest) (*sppb.ResultSet, error) {
+	if req.GetSql() == "error" {
+		return nil, fakeError
+	}
+	return &sppb.ResultSet{Metadata: fakeMetadata}, nil
+}
+
+func (*fakeSpanner) ExecuteStreamingSql(req *sppb.ExecuteSqlRequest, stream sppb.Spanner_ExecuteStreamingSqlServer) error {
+	if err := stream.Send(&sppb.PartialResultSet{Metadata: fakeMetadata}); err != nil {
+		return err
+	}
+	if req.GetSql() == "error" {
+		return fakeError
+	}
+	return stream.Send(&sppb.PartialResultSet{Values: []*structpb.Value{structpb.NewStringValue("1"), structpb.NewListValue(&structpb.ListValue{})}})
+}
+
+func newClient(t *testing.T, opts Options) sppb.SpannerClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	sppb.RegisterSpannerServer(server, &fakeSpanner{})
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(opts)),
+		grpc.WithStreamInterceptor(StreamClientInterceptor(opts)),
+	)
+	if err != nil {
+		t.Fatalf("grpc.NewClient() failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return sppb.NewSpannerClient(conn)
+}
+
+var testParamTypes = map[string]*sppb.Type{
+	"tags": Array(String()),
+	"id":   Int64(),
+}
+
+func request(sql string) *sppb.ExecuteSqlRequest {
+	return &sppb.ExecuteSqlRequest{Session: "session", Sql: sql, ParamTypes: testParamTypes}
+}
+
+const (
+	wantParamTypes = "@id INT64, @tags ARRAY<STRING>"
+	wantRowType    = "n INT64, s ARRAY<PROTO<examples.Book>>"
+)
+
+func executeStreamingSQL(ctx context.Context, client sppb.SpannerClient, req *sppb.ExecuteSqlRequest) error {
+	stream, err := client.ExecuteStreamingSql(ctx, req)
+	if err != nil {
+		return err
+	}
+	for {
+		if _, err := stream.Recv(); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func TestInterceptorLog(t *testing.T) {
+	var buf bytes.Buffer
+	client := newClient(t, Options{Logger: slog.New(slog.NewJSONHandler(&buf, nil))})
+
+	ctx := context.Background()
+	if _, err := client.ExecuteSql(ctx, request("SELECT 1")); err != nil {
+		t.Fatalf("ExecuteSql() failed: %v", err)
+	}
+	if err := executeStreamingSQL(ctx, client, request("SELECT 1")); err != nil {
+		t.Fatalf("ExecuteStreamingSql() failed: %v", err)
+	}
+	// Other methods are not logged.
+	client.BeginTransaction(ctx, &sppb.BeginTransactionRequest{})
+
+	var logs []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("invalid log %q: %v", line, err)
+		}
+		logs = append(logs, m)
+	}
+	if len(logs) != 2 {
+		t.Fatalf("got %d logs, want 2: %v", len(logs), buf.String())
+	}
+	for i, method := range []string{"/google.spanner.v1.Spanner/ExecuteSql", "/google.spanner.v1.Spanner/ExecuteStreamingSql"} {
+		if logs[i]["method"] != method || logs[i]["param_types"] != wantParamTypes || logs[i]["row_type"] != wantRowType {
+			t.Errorf("log %d = %v", i, logs[i])
+		}
+		if _, ok := logs[i]["error"]; ok {
+			t.Errorf("log %d has error: %v", i, logs[i])
+		}
+	}
+}
+
+func TestInterceptorAnnotateErrors(t *testing.T) {
+	var buf bytes.Buffer
+	client := newClient(t, Options{Logger: slog.New(slog.NewTextHandler(&buf, nil)), AnnotateErrors: true})
+
+	ctx := context.Background()
+	_, unaryErr := client.ExecuteSql(ctx, request("error"))
+	streamErr := executeStreamingSQL(ctx, client, request("error"))
+
+	for _, tt := range []struct {
+		desc    string
+		err     error
+		rowType bool
+	}{
+		{"unary", unaryErr, false},
+		{"stream", streamErr, true},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			st, ok := status.FromError(tt.err)
+			if !ok || st.Code() != codes.InvalidArgument {
+				t.Fatalf("status.FromError(%v) = %v, %v, want InvalidArgument", tt.err, st, ok)
+			}
+
+			var e *Error
+			if !errors.As(tt.err, &e) {
+				t.Fatalf("error is not *Error: %T", tt.err)
+			}
+			if e.ParamTypes != wantParamTypes || e.HasRowType != tt.rowType || (tt.rowType && e.RowType != wantRowType) {
+				t.Errorf("*Error = %+v", e)
+			}
+			if !strings.Contains(st.Message(), "param types: "+wantParamTypes) || !strings.HasPrefix(st.Message(), status.Convert(fakeError).Message()) {
+				t.Errorf("message = %q", st.Message())
+			}
+
+			var info *errdetails.ErrorInfo
+			for _, detail := range st.Details() {
+				if detail, ok := detail.(*errdetails.ErrorInfo); ok {
+					info = detail
+				}
+			}
+			if info == nil || info.GetDomain() != ErrorInfoDomain || info.GetMetadata()["param_types"] != wantParamTypes {
+				t.Errorf("ErrorInfo = %v", info)
+			}
+		})
+	}
+
+	if !strings.Contains(buf.String(), "error=") {
+		t.Errorf("errors are not logged: %v", buf.String())
+	}
+}
+
+func TestInterceptorPassThroughErrors(t *testing.T) {
+	client := newClient(t, Options{})
+
+	_, err := client.ExecuteSql(context.Background(), request("error"))
+	var e *Error
+	if errors.As(err, &e) {
+		t.Errorf("error should not be annotated without AnnotateErrors: %v", err)
+	}
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("status.Code want: %v, got: %v", codes.InvalidArgument, status.Code(err))
+	}
+}
+
+func TestAnnotateError(t *testing.T) {
+	decodeErr := errors.New("spanner: failed to decode column n")
+	err := AnnotateError(decodeErr, nil, fakeMetadata, spantype.FormatOptionVerbose)
+	if !errors.Is(err, decodeErr) {
+		t.Errorf("AnnotateError() doesn't wrap the error: %v", err)
+	}
+	if got, want := err.Error(), "spanner: failed to decode column n (row type: n INT64, s ARRAY<examples.Book>)"; got != want {
+		t.Errorf("Error want: %v, got: %v", want, got)
+	}
+	if err := AnnotateError(decodeErr, nil, nil, spantype.FormatOptionVerbose); err != decodeErr {
+		t.Errorf("AnnotateError() without types = %v, want the error as is", err)
+	}
+	if err := AnnotateError(nil, testParamTypes, fakeMetadata, spantype.FormatOptionVerbose); err != nil {
+		t.Errorf("AnnotateError(nil) = %v, want nil", err)
+	}
+}
+
+func TestAnnotateErrorUnknownTypeCode(t *testing.T) {
+	opts := spantype.FormatOptionVerbose
+	opts.Unknown = spantype.UnknownModePanic
+	paramTypes := map[string]*sppb.Type{"id": Int64(), "x": CodeToSimpleType(sppb.TypeCode(-1))}
+
+	err := AnnotateError(errors.New("failed"), paramTypes, nil, opts)
+	var e *Error
+	if !errors.As(err, &e) {
+		t.Fatalf("AnnotateError() should return *Error, but %T", err)
+	}
+	// Param types are formatted in the same way as spantype.FormatParams.
+	if got, want := e.ParamTypes, spantype.FormatParams(nil, paramTypes, opts); got != want {
+		t.Errorf("ParamTypes want: %v, got: %v", want, got)
+	}
+	if got, want := e.ParamTypes, "@id INT64, @x <unknown TypeCode(-1)>"; got != want {
+		t.Errorf("ParamTypes want: %v, got: %v", want, got)
+	}
+}
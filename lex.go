@@ -0,0 +1,150 @@
+package spantype
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	// tokenIdent is an unquoted identifier or keyword.
+	tokenIdent
+	// tokenQuotedIdent is a quoted identifier. e.g. `name` in GoogleSQL, "name" in PostgreSQL.
+	tokenQuotedIdent
+	tokenNumber
+	tokenString
+	// tokenSymbol is a single character punctuation or operator.
+	tokenSymbol
+)
+
+type token struct {
+	kind tokenKind
+	// text is the unquoted value of identifiers, or the raw text of other tokens.
+	text string
+	// pos and end are the byte offsets of the token in the source.
+	pos, end int
+}
+
+// isKeyword reports whether the token is the unquoted keyword kw, case-insensitively.
+func (t token) isKeyword(kw string) bool {
+	return t.kind == tokenIdent && strings.EqualFold(t.text, kw)
+}
+
+func (t token) isSymbol(s string) bool {
+	return t.kind == tokenSymbol && t.text == s
+}
+
+// tokenize splits src into tokens in the given dialect. Comments and whitespace are skipped.
+// The result always ends with a tokenEOF token.
+func tokenize(src string, dialect Dialect) ([]token, error) {
+	var tokens []token
+	i := 0
+	for {
+		i = skipSpacesAndComments(src, i, dialect)
+		if i >= len(src) {
+			return append(tokens, token{kind: tokenEOF, pos: len(src), end: len(src)}), nil
+		}
+
+		start := i
+		c := src[i]
+		switch {
+		case isIdentStart(c):
+			for i < len(src) && isIdentPart(src[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: src[start:i], pos: start, end: i})
+		case '0' <= c && c <= '9':
+			for i < len(src) && (isIdentPart(src[i]) || src[i] == '.' ||
+				((src[i] == '+' || src[i] == '-') && (src[i-1] == 'e' || src[i-1] == 'E'))) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: src[start:i], pos: start, end: i})
+		case c == '`' && dialect == DialectGoogleSQL:
+			end, text, err := scanQuoted(src, i, "`", true)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+			tokens = append(tokens, token{kind: tokenQuotedIdent, text: text, pos: start, end: i})
+		case c == '"' && dialect == DialectPostgreSQL:
+			end, text, err := scanQuoted(src, i, `"`, false)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+			tokens = append(tokens, token{kind: tokenQuotedIdent, text: text, pos: start, end: i})
+		case c == '\'' || c == '"':
+			quote := string(c)
+			if dialect == DialectGoogleSQL && strings.HasPrefix(src[i:], strings.Repeat(quote, 3)) {
+				quote = strings.Repeat(quote, 3)
+			}
+			end, _, err := scanQuoted(src, i, quote, dialect == DialectGoogleSQL)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+			tokens = append(tokens, token{kind: tokenString, text: src[start:i], pos: start, end: i})
+		default:
+			i++
+			tokens = append(tokens, token{kind: tokenSymbol, text: src[start:i], pos: start, end: i})
+		}
+	}
+}
+
+func skipSpacesAndComments(src string, i int, dialect Dialect) int {
+	for i < len(src) {
+		switch {
+		case strings.ContainsRune(" \t\r\n\f", rune(src[i])):
+			i++
+		case strings.HasPrefix(src[i:], "--"), src[i] == '#' && dialect == DialectGoogleSQL:
+			if j := strings.IndexByte(src[i:], '\n'); j >= 0 {
+				i += j + 1
+			} else {
+				i = len(src)
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			if j := strings.Index(src[i+2:], "*/"); j >= 0 {
+				i += j + 4
+			} else {
+				i = len(src)
+			}
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// scanQuoted scans a quoted token starting at src[i] and returns the end offset and the unquoted text.
+// If backslash is true, backslash escapes the next character. Otherwise, a doubled quote is an escaped quote.
+func scanQuoted(src string, i int, quote string, backslash bool) (int, string, error) {
+	var sb strings.Builder
+	for j := i + len(quote); j < len(src); {
+		switch {
+		case backslash && src[j] == '\\' && j+1 < len(src):
+			sb.WriteByte(src[j+1])
+			j += 2
+		case strings.HasPrefix(src[j:], quote):
+			if !backslash && strings.HasPrefix(src[j+len(quote):], quote) {
+				sb.WriteString(quote)
+				j += 2 * len(quote)
+				continue
+			}
+			return j + len(quote), sb.String(), nil
+		default:
+			sb.WriteByte(src[j])
+			j++
+		}
+	}
+	return 0, "", fmt.Errorf("unclosed %v at offset %d", quote, i)
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || '0' <= c && c <= '9' || c == '$'
+}
@@ -0,0 +1,167 @@
+package spantype
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// LintNode is a type in a table evaluated by Rule.
+type LintNode struct {
+	// Table is the table containing the type.
+	Table *Table
+	// Column is the metadata of the column containing the type. It is nil if it is unknown.
+	Column *Column
+	// PrimaryKey is true if the column is a part of the primary key.
+	PrimaryKey bool
+	// Path is the location of the type. It starts with the column.
+	Path Path
+	// Type is the evaluated type.
+	Type *sppb.Type
+}
+
+// IsColumn reports whether the node is the column itself rather than a nested type.
+func (n *LintNode) IsColumn() bool {
+	return len(n.Path) == 1
+}
+
+// Rule is a schema lint rule.
+type Rule struct {
+	// Name is the name of the rule. e.g. `forbid-type`
+	Name string
+	// Message returns the message of findings. If it is nil, Name is used.
+	Message func(node *LintNode) string
+	// Match reports whether the node violates the rule.
+	Match func(node *LintNode) bool
+}
+
+// Finding is a violation of Rule.
+type Finding struct {
+	// Rule is the name of the violated rule.
+	Rule string
+	// Table is the name of the table.
+	Table string
+	// Path is the location of the violating type.
+	Path Path
+	// Type is the violating type.
+	Type *sppb.Type
+	// Message describes the violation.
+	Message string
+}
+
+// String formats the finding. e.g. `Singers.Tags[]: ARRAY<JSON> is forbidden (forbid-type)`
+func (f Finding) String() string {
+	location := f.Path.String()
+	if f.Table != "" {
+		location = f.Table + "." + location
+	}
+	return fmt.Sprintf("%v: %v (%v)", location, f.Message, f.Rule)
+}
+
+// Lint evaluates rules against all types in the tables, and returns findings in table, column, and rule order.
+func Lint(tables []*Table, rules []Rule) []Finding {
+	var findings []Finding
+	for _, table := range tables {
+		WalkStructFields(table.RowType.GetFields(), func(path Path, typ *sppb.Type) bool {
+			node := &LintNode{Table: table, Path: path, Type: typ}
+			if i := path[0].Index; i < len(table.Columns) {
+				node.Column = table.Columns[i]
+			}
+			node.PrimaryKey = containsFold(table.PrimaryKey, path[0].Name)
+
+			for _, rule := range rules {
+				if !rule.Match(node) {
+					continue
+				}
+				msg := rule.Name
+				if rule.Message != nil {
+					msg = rule.Message(node)
+				}
+				findings = append(findings, Finding{Rule: rule.Name, Table: table.Name, Path: path, Type: typ, Message: msg})
+			}
+			return true
+		})
+	}
+	return findings
+}
+
+// LintStructFields is like Lint, but it evaluates rules against a row type without table.
+func LintStructFields(fields []*sppb.StructType_Field, rules []Rule) []Finding {
+	return Lint([]*Table{{RowType: &sppb.StructType{Fields: fields}}}, rules)
+}
+
+// ForbidType returns a Rule which forbids typ in the given tables, or in all tables if tables is empty.
+// Table names are compared case-insensitively, and types are compared including annotations. e.g. `ARRAY<JSON>`, `FLOAT32`
+func ForbidType(typ *sppb.Type, tables ...string) Rule {
+	return Rule{
+		Name: "forbid-type",
+		Message: func(node *LintNode) string {
+			return fmt.Sprintf("%v is forbidden", FormatTypeMoreVerbose(node.Type))
+		},
+		Match: func(node *LintNode) bool {
+			if len(tables) > 0 && !containsFold(tables, node.Table.Name) {
+				return false
+			}
+			return proto.Equal(typ, node.Type)
+		},
+	}
+}
+
+// ForbidMaxLengthInKey returns a Rule which forbids `STRING(MAX)` and `BYTES(MAX)` primary key columns.
+func ForbidMaxLengthInKey() Rule {
+	return Rule{
+		Name: "forbid-max-length-key",
+		Message: func(node *LintNode) string {
+			return fmt.Sprintf("%v(MAX) is forbidden in primary key", FormatTypeCode(node.Type.GetCode(), UnknownModeVerbose))
+		},
+		Match: func(node *LintNode) bool {
+			if !node.IsColumn() || !node.PrimaryKey || node.Column == nil {
+				return false
+			}
+			code := node.Type.GetCode()
+			return (code == sppb.TypeCode_STRING || code == sppb.TypeCode_BYTES) && node.Column.Length == LengthMax
+		},
+	}
+}
+
+// RequireRegisteredProto returns a Rule which requires `PROTO` and `ENUM` types to be resolved by resolver
+// as a message or an enum respectively. If resolver is nil, protoregistry.GlobalFiles is used.
+func RequireRegisteredProto(resolver DescriptorResolver) Rule {
+	if resolver == nil {
+		resolver = protoregistry.GlobalFiles
+	}
+	return Rule{
+		Name: "require-registered-proto",
+		Message: func(node *LintNode) string {
+			return fmt.Sprintf("%v is not registered", FormatTypeMoreVerbose(node.Type))
+		},
+		Match: func(node *LintNode) bool {
+			var ok bool
+			switch node.Type.GetCode() {
+			case sppb.TypeCode_PROTO:
+				desc, err := resolver.FindDescriptorByName(protoreflect.FullName(node.Type.GetProtoTypeFqn()))
+				_, ok = desc.(protoreflect.MessageDescriptor)
+				ok = ok && err == nil
+			case sppb.TypeCode_ENUM:
+				desc, err := resolver.FindDescriptorByName(protoreflect.FullName(node.Type.GetProtoTypeFqn()))
+				_, ok = desc.(protoreflect.EnumDescriptor)
+				ok = ok && err == nil
+			default:
+				return false
+			}
+			return !ok
+		},
+	}
+}
+
+// containsFold reports whether names contains name. Identifiers are case-insensitive.
+func containsFold(names []string, name string) bool {
+	return slices.ContainsFunc(names, func(n string) bool {
+		return strings.EqualFold(n, name)
+	})
+}
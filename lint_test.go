@@ -0,0 +1,104 @@
+package spantype
+
+import (
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	. "github.com/apstndb/spantype/typector"
+)
+
+func TestWalk(t *testing.T) {
+	typ := StructTypeFieldsToStructType([]*sppb.StructType_Field{
+		NameTypeToStructTypeField("outer", ElemTypeToArrayType(StructTypeFieldsToStructType([]*sppb.StructType_Field{
+			CodeToUnnamedStructTypeField(sppb.TypeCode_INT64),
+			NameCodeToStructTypeField("n", sppb.TypeCode_STRING),
+		}))),
+	})
+
+	var got []string
+	Walk(typ, func(path Path, typ *sppb.Type) bool {
+		got = append(got, path.String()+" "+FormatTypeSimplest(typ))
+		return typ.GetCode() != sppb.TypeCode_STRUCT || len(path) == 0
+	})
+
+	want := []string{" STRUCT", "outer ARRAY", "outer[] STRUCT"}
+	if len(want) != len(got) {
+		t.Fatalf("Walk want: %q, got: %q", want, got)
+	}
+	for i := range want {
+		if want[i] != got[i] {
+			t.Errorf("Walk want: %q, got: %q", want, got)
+		}
+	}
+
+	got = nil
+	Walk(typ, func(path Path, typ *sppb.Type) bool {
+		got = append(got, path.String())
+		return true
+	})
+	if want := "outer[].#0"; got[3] != want {
+		t.Errorf("Walk want: %v, got: %v", want, got[3])
+	}
+}
+
+func TestLint(t *testing.T) {
+	files := testFiles(t)
+	tables, err := ParseDDL(`
+CREATE TABLE Prices (
+  Id STRING(MAX) NOT NULL,
+  Price FLOAT32,
+  Docs ARRAY<JSON>,
+  Book examples.shop.Book,
+  Unknown examples.shop.Unknown,
+) PRIMARY KEY (ID);
+CREATE TABLE Metrics (
+  Id STRING(36) NOT NULL,
+  Value FLOAT32,
+) PRIMARY KEY (Id);
+`, ParseOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules := []Rule{
+		ForbidMaxLengthInKey(),
+		RequireRegisteredProto(files),
+		ForbidType(Float32(), "prices"),
+		ForbidType(ElemTypeToArrayType(JSON())),
+	}
+
+	want := []string{
+		"Prices.Id: STRING(MAX) is forbidden in primary key (forbid-max-length-key)",
+		"Prices.Price: FLOAT32 is forbidden (forbid-type)",
+		"Prices.Docs: ARRAY<JSON> is forbidden (forbid-type)",
+		"Prices.Unknown: PROTO<examples.shop.Unknown> is not registered (require-registered-proto)",
+	}
+	got := Lint(tables, rules)
+	if len(want) != len(got) {
+		t.Fatalf("Lint want: %q, got: %v", want, got)
+	}
+	for i := range want {
+		if want[i] != got[i].String() {
+			t.Errorf("Lint want: %v, got: %v", want[i], got[i])
+		}
+	}
+
+	findings := LintStructFields(tables[0].RowType.GetFields(), []Rule{ForbidType(JSON())})
+	if len(findings) != 1 || findings[0].String() != "Docs[]: JSON is forbidden (forbid-type)" {
+		t.Errorf("LintStructFields got: %v", findings)
+	}
+}
+
+func TestRequireRegisteredProtoGlobalFiles(t *testing.T) {
+	fields := []*sppb.StructType_Field{
+		NameTypeToStructTypeField("t", FQNToProtoType("google.spanner.v1.Type")),
+		NameTypeToStructTypeField("c", FQNToEnumType("google.spanner.v1.TypeCode")),
+		NameTypeToStructTypeField("b", FQNToProtoType("examples.shop.Book")),
+	}
+	findings := LintStructFields(fields, []Rule{RequireRegisteredProto(nil)})
+	want := "b: PROTO<examples.shop.Book> is not registered (require-registered-proto)"
+	if len(findings) != 1 || findings[0].String() != want {
+		t.Errorf("LintStructFields want: %v, got: %v", want, findings)
+	}
+}
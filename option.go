@@ -0,0 +1,319 @@
+package spantype
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Names of modes used by ParseFormatOption. They are indexed by the mode values.
+var (
+	structModeNames     = []string{StructModeBase: "base", StructModeRecursive: "recursive", StructModeRecursiveWithName: "named"}
+	protoEnumModeNames  = []string{ProtoEnumModeBase: "base", ProtoEnumModeLeaf: "leaf", ProtoEnumModeFull: "full", ProtoEnumModeLeafWithKind: "leaf-kind", ProtoEnumModeFullWithKind: "full-kind", ProtoEnumModeRelative: "relative", ProtoEnumModeRelativeWithKind: "relative-kind"}
+	arrayModeNames      = []string{ArrayModeBase: "base", ArrayModeRecursive: "recursive"}
+	unknownModeNames    = []string{UnknownModeUnknown: "unknown", UnknownModeTypeCode: "code", UnknownModeVerbose: "verbose", UnknownModePanic: "panic"}
+	annotationModeNames = []string{AnnotationModeBase: "base", AnnotationModeName: "name"}
+	protoValueModeNames = []string{ProtoValueModeRaw: "raw", ProtoValueModeText: "text", ProtoValueModeJSON: "json"}
+	enumValueModeNames  = []string{EnumValueModeRaw: "raw", EnumValueModeName: "name"}
+)
+
+// formatOptionPresets is the names of preset FormatOptions.
+var formatOptionPresets = map[string]FormatOption{
+	"simplest": FormatOptionSimplest,
+	"simple":   FormatOptionSimple,
+	"normal":   FormatOptionNormal,
+	"verbose":  FormatOptionVerbose,
+	"more":     FormatOptionMoreVerbose,
+}
+
+// LookupFormatOption returns the preset FormatOption of the name, which is one of simplest, simple, normal, verbose, and more.
+func LookupFormatOption(name string) (FormatOption, bool) {
+	opts, ok := formatOptionPresets[strings.ToLower(name)]
+	return opts, ok
+}
+
+// ParseFormatOption parses a comma-separated FormatOption specification, e.g. `verbose,struct=named,proto=full-kind`.
+// The first element can be a preset name (simplest, simple, normal, verbose, or more) as a baseline,
+// and the following `key=value` elements override it. Keys and values are:
+//
+//   - struct: base, recursive, named
+//   - proto, enum: base, leaf, full, leaf-kind, full-kind, relative, relative-kind
+//   - array: base, recursive
+//   - unknown: unknown, code, verbose, panic
+//   - annotation: base, name
+//   - proto-value: raw, text, json
+//   - enum-value: raw, name
+//   - package: the proto package for relative and relative-kind
+//   - strict: true, false
+//
+// Without a preset, the baseline is the zero FormatOption.
+func ParseFormatOption(s string) (FormatOption, error) {
+	var opts FormatOption
+	for i, elem := range strings.Split(s, ",") {
+		elem = strings.TrimSpace(elem)
+		if elem == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(elem, "=")
+		if !ok {
+			preset, found := LookupFormatOption(elem)
+			switch {
+			case !found:
+				return FormatOption{}, fmt.Errorf("unknown FormatOption preset: %q", elem)
+			case i > 0:
+				return FormatOption{}, fmt.Errorf("FormatOption preset must be the first element: %q", elem)
+			}
+			opts = preset
+			continue
+		}
+
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		var err error
+		switch strings.ToLower(key) {
+		case "struct":
+			opts.Struct, err = parseModeName[StructMode](structModeNames, key, value)
+		case "proto":
+			opts.Proto, err = parseModeName[ProtoEnumMode](protoEnumModeNames, key, value)
+		case "enum":
+			opts.Enum, err = parseModeName[ProtoEnumMode](protoEnumModeNames, key, value)
+		case "array":
+			opts.Array, err = parseModeName[ArrayMode](arrayModeNames, key, value)
+		case "unknown":
+			opts.Unknown, err = parseModeName[UnknownMode](unknownModeNames, key, value)
+		case "annotation":
+			opts.Annotation, err = parseModeName[AnnotationMode](annotationModeNames, key, value)
+		case "proto-value":
+			opts.ProtoValue, err = parseModeName[ProtoValueMode](protoValueModeNames, key, value)
+		case "enum-value":
+			opts.EnumValue, err = parseModeName[EnumValueMode](enumValueModeNames, key, value)
+		case "package":
+			opts.Package = value
+		case "strict":
+			opts.Strict, err = strconv.ParseBool(value)
+		default:
+			err = fmt.Errorf("unknown FormatOption key: %q", key)
+		}
+		if err != nil {
+			return FormatOption{}, err
+		}
+	}
+	return opts, nil
+}
+
+// parseModeName returns the mode whose name is s.
+func parseModeName[T ~int](names []string, key, s string) (T, error) {
+	for i, name := range names {
+		if strings.EqualFold(name, s) {
+			return T(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown %v mode: %q, must be one of %v", key, s, strings.Join(names, ", "))
+}
+
+// String returns the specification of ParseFormatOption with all keys. e.g. `struct=named,proto=full,...`
+// FormatOption.Resolver is not included.
+func (o FormatOption) String() string {
+	b, err := o.MarshalText()
+	if err != nil {
+		return fmt.Sprintf("%+v", formatOptionFields(o))
+	}
+	return string(b)
+}
+
+// formatOptionFields has the same fields as FormatOption without methods.
+type formatOptionFields FormatOption
+
+// MarshalText implements encoding.TextMarshaler. It returns an error if a mode is unknown.
+func (o FormatOption) MarshalText() ([]byte, error) {
+	var b []byte
+	appendMode := func(key string, names []string, mode int) error {
+		if mode < 0 || mode >= len(names) {
+			return fmt.Errorf("unknown %v mode: %d", key, mode)
+		}
+		if len(b) > 0 {
+			b = append(b, ',')
+		}
+		b = append(b, key+"="+names[mode]...)
+		return nil
+	}
+	for _, m := range []struct {
+		key   string
+		names []string
+		mode  int
+	}{
+		{"struct", structModeNames, int(o.Struct)},
+		{"proto", protoEnumModeNames, int(o.Proto)},
+		{"enum", protoEnumModeNames, int(o.Enum)},
+		{"array", arrayModeNames, int(o.Array)},
+		{"unknown", unknownModeNames, int(o.Unknown)},
+		{"annotation", annotationModeNames, int(o.Annotation)},
+		{"proto-value", protoValueModeNames, int(o.ProtoValue)},
+		{"enum-value", enumValueModeNames, int(o.EnumValue)},
+	} {
+		if err := appendMode(m.key, m.names, m.mode); err != nil {
+			return nil, err
+		}
+	}
+	if o.Package != "" {
+		if strings.Contains(o.Package, ",") {
+			return nil, fmt.Errorf("invalid package: %q", o.Package)
+		}
+		b = append(b, ",package="+o.Package...)
+	}
+	if o.Strict {
+		b = append(b, ",strict=true"...)
+	}
+	return b, nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using ParseFormatOption.
+// FormatOption.Resolver is kept as is.
+func (o *FormatOption) UnmarshalText(text []byte) error {
+	opts, err := ParseFormatOption(string(text))
+	if err != nil {
+		return err
+	}
+	opts.Resolver = o.Resolver
+	*o = opts
+	return nil
+}
+
+// Set implements flag.Value using ParseFormatOption.
+func (o *FormatOption) Set(s string) error {
+	return o.UnmarshalText([]byte(s))
+}
+
+// String returns the name of the StructMode, e.g. `named`.
+func (m StructMode) String() string { return modeString(structModeNames, "StructMode", m) }
+
+// MarshalText implements encoding.TextMarshaler.
+func (m StructMode) MarshalText() ([]byte, error) {
+	return marshalMode(structModeNames, "StructMode", m)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (m *StructMode) UnmarshalText(text []byte) error {
+	return unmarshalMode(structModeNames, "struct", m, text)
+}
+
+// Set implements flag.Value.
+func (m *StructMode) Set(s string) error { return m.UnmarshalText([]byte(s)) }
+
+// String returns the name of the ProtoEnumMode, e.g. `full-kind`.
+func (m ProtoEnumMode) String() string { return modeString(protoEnumModeNames, "ProtoEnumMode", m) }
+
+// MarshalText implements encoding.TextMarshaler.
+func (m ProtoEnumMode) MarshalText() ([]byte, error) {
+	return marshalMode(protoEnumModeNames, "ProtoEnumMode", m)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (m *ProtoEnumMode) UnmarshalText(text []byte) error {
+	return unmarshalMode(protoEnumModeNames, "proto/enum", m, text)
+}
+
+// Set implements flag.Value.
+func (m *ProtoEnumMode) Set(s string) error { return m.UnmarshalText([]byte(s)) }
+
+// String returns the name of the ArrayMode, e.g. `recursive`.
+func (m ArrayMode) String() string { return modeString(arrayModeNames, "ArrayMode", m) }
+
+// MarshalText implements encoding.TextMarshaler.
+func (m ArrayMode) MarshalText() ([]byte, error) { return marshalMode(arrayModeNames, "ArrayMode", m) }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (m *ArrayMode) UnmarshalText(text []byte) error {
+	return unmarshalMode(arrayModeNames, "array", m, text)
+}
+
+// Set implements flag.Value.
+func (m *ArrayMode) Set(s string) error { return m.UnmarshalText([]byte(s)) }
+
+// String returns the name of the UnknownMode, e.g. `verbose`.
+func (m UnknownMode) String() string { return modeString(unknownModeNames, "UnknownMode", m) }
+
+// MarshalText implements encoding.TextMarshaler.
+func (m UnknownMode) MarshalText() ([]byte, error) {
+	return marshalMode(unknownModeNames, "UnknownMode", m)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (m *UnknownMode) UnmarshalText(text []byte) error {
+	return unmarshalMode(unknownModeNames, "unknown", m, text)
+}
+
+// Set implements flag.Value.
+func (m *UnknownMode) Set(s string) error { return m.UnmarshalText([]byte(s)) }
+
+// String returns the name of the AnnotationMode, e.g. `name`.
+func (m AnnotationMode) String() string { return modeString(annotationModeNames, "AnnotationMode", m) }
+
+// MarshalText implements encoding.TextMarshaler.
+func (m AnnotationMode) MarshalText() ([]byte, error) {
+	return marshalMode(annotationModeNames, "AnnotationMode", m)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (m *AnnotationMode) UnmarshalText(text []byte) error {
+	return unmarshalMode(annotationModeNames, "annotation", m, text)
+}
+
+// Set implements flag.Value.
+func (m *AnnotationMode) Set(s string) error { return m.UnmarshalText([]byte(s)) }
+
+// String returns the name of the ProtoValueMode, e.g. `text`.
+func (m ProtoValueMode) String() string { return modeString(protoValueModeNames, "ProtoValueMode", m) }
+
+// MarshalText implements encoding.TextMarshaler.
+func (m ProtoValueMode) MarshalText() ([]byte, error) {
+	return marshalMode(protoValueModeNames, "ProtoValueMode", m)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (m *ProtoValueMode) UnmarshalText(text []byte) error {
+	return unmarshalMode(protoValueModeNames, "proto-value", m, text)
+}
+
+// Set implements flag.Value.
+func (m *ProtoValueMode) Set(s string) error { return m.UnmarshalText([]byte(s)) }
+
+// String returns the name of the EnumValueMode, e.g. `name`.
+func (m EnumValueMode) String() string { return modeString(enumValueModeNames, "EnumValueMode", m) }
+
+// MarshalText implements encoding.TextMarshaler.
+func (m EnumValueMode) MarshalText() ([]byte, error) {
+	return marshalMode(enumValueModeNames, "EnumValueMode", m)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (m *EnumValueMode) UnmarshalText(text []byte) error {
+	return unmarshalMode(enumValueModeNames, "enum-value", m, text)
+}
+
+// Set implements flag.Value.
+func (m *EnumValueMode) Set(s string) error { return m.UnmarshalText([]byte(s)) }
+
+// modeString returns the name of the mode, or e.g. `StructMode(5)` for unknown modes.
+func modeString[T ~int](names []string, typeName string, m T) string {
+	if m < 0 || int(m) >= len(names) {
+		return typeName + "(" + strconv.Itoa(int(m)) + ")"
+	}
+	return names[m]
+}
+
+func marshalMode[T ~int](names []string, typeName string, m T) ([]byte, error) {
+	if m < 0 || int(m) >= len(names) {
+		return nil, fmt.Errorf("unknown %v: %d", typeName, int(m))
+	}
+	return []byte(names[m]), nil
+}
+
+func unmarshalMode[T ~int](names []string, key string, m *T, text []byte) error {
+	mode, err := parseModeName[T](names, key, string(text))
+	if err != nil {
+		return err
+	}
+	*m = mode
+	return nil
+}
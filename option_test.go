@@ -0,0 +1,169 @@
+package spantype
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestParseFormatOption(t *testing.T) {
+	for _, tt := range []struct {
+		input string
+		want  FormatOption
+	}{
+		{"verbose", FormatOptionVerbose},
+		{"MORE", FormatOptionMoreVerbose},
+		{"", FormatOption{}},
+		{"struct=named", FormatOption{Struct: StructModeRecursiveWithName}},
+		{"simplest, array=recursive, unknown=verbose", func() FormatOption {
+			opts := FormatOptionSimplest
+			opts.Array = ArrayModeRecursive
+			opts.Unknown = UnknownModeVerbose
+			return opts
+		}()},
+		{"verbose,struct=recursive,proto=full-kind,enum=relative-kind,package=examples.shop,annotation=name", func() FormatOption {
+			opts := FormatOptionVerbose
+			opts.Struct = StructModeRecursive
+			opts.Proto = ProtoEnumModeFullWithKind
+			opts.Enum = ProtoEnumModeRelativeWithKind
+			opts.Package = "examples.shop"
+			opts.Annotation = AnnotationModeName
+			return opts
+		}()},
+		{"normal,proto-value=json,enum-value=raw,proto=leaf-kind", func() FormatOption {
+			opts := FormatOptionNormal
+			opts.ProtoValue = ProtoValueModeJSON
+			opts.EnumValue = EnumValueModeRaw
+			opts.Proto = ProtoEnumModeLeafWithKind
+			return opts
+		}()},
+	} {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseFormatOption(tt.input)
+			if err != nil {
+				t.Fatalf("ParseFormatOption(%q) failed: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFormatOption(%q) want: %+v, got: %+v", tt.input, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseFormatOptionError(t *testing.T) {
+	for _, input := range []string{
+		"unknown",
+		"struct=named,verbose",
+		"struct=nested",
+		"proto=",
+		"color=red",
+	} {
+		t.Run(input, func(t *testing.T) {
+			if got, err := ParseFormatOption(input); err == nil {
+				t.Errorf("ParseFormatOption(%q) = %+v, want error", input, got)
+			}
+		})
+	}
+}
+
+func TestFormatOptionText(t *testing.T) {
+	for _, opts := range []FormatOption{FormatOptionSimplest, FormatOptionSimple, FormatOptionNormal, FormatOptionVerbose, FormatOptionMoreVerbose,
+		{Struct: StructModeRecursive, Proto: ProtoEnumModeRelative, Package: "examples.shop", Strict: true}} {
+		b, err := opts.MarshalText()
+		if err != nil {
+			t.Fatalf("MarshalText() failed: %v", err)
+		}
+		var got FormatOption
+		if err := got.UnmarshalText(b); err != nil {
+			t.Fatalf("UnmarshalText(%q) failed: %v", b, err)
+		}
+		if got != opts {
+			t.Errorf("round trip of %q want: %v, got: %v", b, opts, got)
+		}
+	}
+
+	if got, want := FormatOptionMoreVerbose.String(),
+		"struct=named,proto=full-kind,enum=full-kind,array=recursive,unknown=verbose,annotation=base,proto-value=text,enum-value=name"; got != want {
+		t.Errorf("String want: %v, got: %v", want, got)
+	}
+	if _, err := (FormatOption{Struct: 100}).MarshalText(); err == nil {
+		t.Errorf("MarshalText() with unknown mode should fail")
+	}
+}
+
+func TestFormatOptionJSON(t *testing.T) {
+	type config struct {
+		Format FormatOption  `json:"format"`
+		Struct StructMode    `json:"struct"`
+		Proto  ProtoEnumMode `json:"proto"`
+	}
+	var c config
+	if err := json.Unmarshal([]byte(`{"format": "verbose,proto=full-kind", "struct": "named", "proto": "leaf-kind"}`), &c); err != nil {
+		t.Fatalf("json.Unmarshal() failed: %v", err)
+	}
+	wantFormat := FormatOptionVerbose
+	wantFormat.Proto = ProtoEnumModeFullWithKind
+	if c.Format != wantFormat || c.Struct != StructModeRecursiveWithName || c.Proto != ProtoEnumModeLeafWithKind {
+		t.Errorf("json.Unmarshal() = %+v", c)
+	}
+
+	b, err := json.Marshal(config{Format: FormatOptionSimplest, Struct: StructModeBase, Proto: ProtoEnumModeRelative})
+	if err != nil {
+		t.Fatalf("json.Marshal() failed: %v", err)
+	}
+	want := `{"format":"struct=base,proto=base,enum=base,array=base,unknown=code,annotation=base,proto-value=raw,enum-value=raw","struct":"base","proto":"relative"}`
+	if string(b) != want {
+		t.Errorf("json.Marshal want: %s, got: %s", want, b)
+	}
+}
+
+func TestModeFlag(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	opts := FormatOptionNormal
+	unknown := UnknownModeUnknown
+	fs.Var(&opts, "format", "")
+	fs.Var(&unknown, "unknown", "")
+	if err := fs.Parse([]string{"-format=simple,array=base", "-unknown=verbose"}); err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	want := FormatOptionSimple
+	want.Array = ArrayModeBase
+	if opts != want || unknown != UnknownModeVerbose {
+		t.Errorf("got %v and %v", opts, unknown)
+	}
+	if err := fs.Parse([]string{"-unknown=bogus"}); err == nil {
+		t.Errorf("Parse() with unknown mode should fail")
+	}
+}
+
+func TestModeString(t *testing.T) {
+	for _, tt := range []struct {
+		mode fmt.Stringer
+		want string
+	}{
+		{StructModeRecursiveWithName, "named"},
+		{ProtoEnumModeRelativeWithKind, "relative-kind"},
+		{ArrayModeRecursive, "recursive"},
+		{UnknownModePanic, "panic"},
+		{AnnotationModeName, "name"},
+		{ProtoValueModeJSON, "json"},
+		{EnumValueModeName, "name"},
+		{StructMode(5), "StructMode(5)"},
+	} {
+		if got := tt.mode.String(); got != tt.want {
+			t.Errorf("String want: %v, got: %v", tt.want, got)
+		}
+	}
+}
+
+func TestLookupFormatOption(t *testing.T) {
+	if opts, ok := LookupFormatOption("verbose"); !ok || opts != FormatOptionVerbose {
+		t.Errorf("LookupFormatOption(verbose) = %v, %v", opts, ok)
+	}
+	if _, ok := LookupFormatOption("loud"); ok {
+		t.Errorf("LookupFormatOption(loud) should fail")
+	}
+}
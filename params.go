@@ -0,0 +1,248 @@
+package spantype
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ParamError is a problem of a query parameter reported by CheckParams.
+type ParamError struct {
+	// Name is the parameter name without `@`.
+	Name string
+	// Path is the location of the value in the parameter.
+	Path Path
+	// Message describes the problem.
+	Message string
+}
+
+func (e *ParamError) Error() string {
+	if len(e.Path) == 0 {
+		return fmt.Sprintf("@%v: %v", e.Name, e.Message)
+	}
+	if e.Path[0].Elem {
+		return fmt.Sprintf("@%v%v: %v", e.Name, e.Path, e.Message)
+	}
+	return fmt.Sprintf("@%v.%v: %v", e.Name, e.Path, e.Message)
+}
+
+// FormatParams formats query parameters sorted by name as `@name TYPE = value`, separated by `, `.
+// e.g. `@id INT64 = 1, @tags ARRAY<STRING> = ["a"]`
+// Types are formatted by TryFormatType, and values by FormatValue.
+// Parameters without param_type are formatted with the type inferred by InferParamType in a comment,
+// e.g. `@name /* STRING */ = "a"`, and parameters without value are formatted without `= value`.
+// It never panics. Types which can't be formatted, e.g. unknown type codes with UnknownModePanic,
+// are formatted as the error in angle brackets. Use TryFormatParams to get an error instead.
+func FormatParams(params *structpb.Struct, paramTypes map[string]*sppb.Type, opts FormatOption) string {
+	s, _ := formatParams(params, paramTypes, opts, false)
+	return s
+}
+
+// TryFormatParams is like FormatParams, but it returns an error of the first type which can't be formatted.
+func TryFormatParams(params *structpb.Struct, paramTypes map[string]*sppb.Type, opts FormatOption) (string, error) {
+	return formatParams(params, paramTypes, opts, true)
+}
+
+func formatParams(params *structpb.Struct, paramTypes map[string]*sppb.Type, opts FormatOption, returnErr bool) (string, error) {
+	values := params.GetFields()
+
+	var elems []string
+	for _, name := range paramNames(values, paramTypes) {
+		value, hasValue := values[name]
+		typ, hasType := paramTypes[name]
+
+		var sb strings.Builder
+		sb.WriteString("@" + name)
+		if hasType {
+			str, err := TryFormatType(typ, opts)
+			if err != nil {
+				if returnErr {
+					return "", fmt.Errorf("@%v: %w", name, err)
+				}
+				str = "<" + err.Error() + ">"
+			}
+			sb.WriteString(" " + str)
+		} else if typ = InferParamType(value); typ != nil {
+			sb.WriteString(" /* " + FormatType(typ, opts) + " */")
+		}
+		if hasValue {
+			sb.WriteString(" = " + FormatValue(typ, value, opts))
+		}
+		elems = append(elems, sb.String())
+	}
+	return strings.Join(elems, ", "), nil
+}
+
+// FormatExecuteSqlParams is like FormatParams, but it formats params and param_types of ExecuteSqlRequest.
+func FormatExecuteSqlParams(req *sppb.ExecuteSqlRequest, opts FormatOption) string {
+	return FormatParams(req.GetParams(), req.GetParamTypes(), opts)
+}
+
+// CheckParams reports all problems of query parameters: parameters without param_type, param_types without value,
+// and values whose shape doesn't match the declared type, e.g. a list value of a non-`ARRAY` type.
+// `NULL` matches any type. The returned error joins *ParamError values sorted by name, and it is nil if there is no problem.
+func CheckParams(params *structpb.Struct, paramTypes map[string]*sppb.Type) error {
+	values := params.GetFields()
+
+	var errs []error
+	for _, name := range paramNames(values, paramTypes) {
+		value, hasValue := values[name]
+		typ, hasType := paramTypes[name]
+		switch {
+		case !hasType:
+			msg := "param_type is missing"
+			if inferred := InferParamType(value); inferred != nil {
+				msg += "; inferred as " + FormatTypeMoreVerbose(inferred)
+			}
+			errs = append(errs, &ParamError{Name: name, Message: msg})
+		case !hasValue:
+			errs = append(errs, &ParamError{Name: name, Message: "value is missing"})
+		default:
+			errs = append(errs, checkParamValue(name, nil, typ, value)...)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CheckExecuteSqlParams is like CheckParams, but it checks params and param_types of ExecuteSqlRequest.
+func CheckExecuteSqlParams(req *sppb.ExecuteSqlRequest) error {
+	return CheckParams(req.GetParams(), req.GetParamTypes())
+}
+
+// InferParamType infers the type of an untyped parameter from the shape of the value.
+// Booleans are `BOOL`, numbers are `FLOAT64`, strings are `STRING`, and lists are `ARRAY` of the type of non-`NULL` elements.
+// It returns nil if the type can't be inferred, e.g. `NULL`, empty lists, and lists of mixed types.
+// Note that Cloud Spanner may coerce untyped strings to other types depending on the query.
+func InferParamType(value *structpb.Value) *sppb.Type {
+	switch kind := value.GetKind().(type) {
+	case *structpb.Value_BoolValue:
+		return &sppb.Type{Code: sppb.TypeCode_BOOL}
+	case *structpb.Value_NumberValue:
+		return &sppb.Type{Code: sppb.TypeCode_FLOAT64}
+	case *structpb.Value_StringValue:
+		return &sppb.Type{Code: sppb.TypeCode_STRING}
+	case *structpb.Value_ListValue:
+		var elem *sppb.Type
+		for _, v := range kind.ListValue.GetValues() {
+			if _, ok := v.GetKind().(*structpb.Value_NullValue); ok {
+				continue
+			}
+			// Nested ARRAY is not allowed.
+			typ := InferParamType(v)
+			if typ == nil || typ.GetCode() == sppb.TypeCode_ARRAY {
+				return nil
+			}
+			if elem != nil && elem.GetCode() != typ.GetCode() {
+				return nil
+			}
+			elem = typ
+		}
+		if elem == nil {
+			return nil
+		}
+		return &sppb.Type{Code: sppb.TypeCode_ARRAY, ArrayElementType: elem}
+	default:
+		return nil
+	}
+}
+
+// paramNames returns the union of names in values and types in sorted order.
+func paramNames(values map[string]*structpb.Value, types map[string]*sppb.Type) []string {
+	names := make([]string, 0, len(values)+len(types))
+	for name := range values {
+		names = append(names, name)
+	}
+	for name := range types {
+		if _, ok := values[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// checkParamValue reports values in the tree whose shape doesn't match the type.
+func checkParamValue(name string, path Path, typ *sppb.Type, value *structpb.Value) []error {
+	report := func(format string, args ...any) []error {
+		return []error{&ParamError{Name: name, Path: path, Message: fmt.Sprintf(format, args...)}}
+	}
+
+	if value == nil {
+		return report("value is missing")
+	}
+	if _, ok := value.GetKind().(*structpb.Value_NullValue); ok {
+		return nil
+	}
+
+	switch code := typ.GetCode(); code {
+	case sppb.TypeCode_ARRAY:
+		list, ok := value.GetKind().(*structpb.Value_ListValue)
+		if !ok {
+			return report("%v value for %v", valueKindName(value), FormatTypeMoreVerbose(typ))
+		}
+		var errs []error
+		for _, v := range list.ListValue.GetValues() {
+			errs = append(errs, checkParamValue(name, path.Append(PathElem{Elem: true}), typ.GetArrayElementType(), v)...)
+		}
+		return errs
+	case sppb.TypeCode_STRUCT:
+		list, ok := value.GetKind().(*structpb.Value_ListValue)
+		if !ok {
+			return report("%v value for %v", valueKindName(value), FormatTypeMoreVerbose(typ))
+		}
+		fields := typ.GetStructType().GetFields()
+		if got := len(list.ListValue.GetValues()); got != len(fields) {
+			return report("%v values for %v fields of %v", got, len(fields), FormatTypeMoreVerbose(typ))
+		}
+		var errs []error
+		for i, v := range list.ListValue.GetValues() {
+			errs = append(errs, checkParamValue(name, path.Append(PathElem{Name: fields[i].GetName(), Index: i}), fields[i].GetType(), v)...)
+		}
+		return errs
+	case sppb.TypeCode_BOOL:
+		if _, ok := value.GetKind().(*structpb.Value_BoolValue); !ok {
+			return report("%v value for %v", valueKindName(value), FormatTypeMoreVerbose(typ))
+		}
+	case sppb.TypeCode_FLOAT64, sppb.TypeCode_FLOAT32:
+		// Non-finite floats are encoded as `NaN`, `Infinity`, or `-Infinity`.
+		switch kind := value.GetKind().(type) {
+		case *structpb.Value_NumberValue:
+		case *structpb.Value_StringValue:
+			if !slices.Contains([]string{"NaN", "Infinity", "-Infinity"}, kind.StringValue) {
+				return report("string value %q for %v", kind.StringValue, FormatTypeMoreVerbose(typ))
+			}
+		default:
+			return report("%v value for %v", valueKindName(value), FormatTypeMoreVerbose(typ))
+		}
+	case sppb.TypeCode_TYPE_CODE_UNSPECIFIED:
+		return report("type code is unspecified")
+	default:
+		// Other types, including INT64 and NUMERIC, are encoded as string.
+		if _, ok := value.GetKind().(*structpb.Value_StringValue); !ok {
+			return report("%v value for %v", valueKindName(value), FormatTypeMoreVerbose(typ))
+		}
+	}
+	return nil
+}
+
+// valueKindName returns the name of the JSON kind of the value for messages.
+func valueKindName(value *structpb.Value) string {
+	switch value.GetKind().(type) {
+	case *structpb.Value_BoolValue:
+		return "bool"
+	case *structpb.Value_NumberValue:
+		return "number"
+	case *structpb.Value_StringValue:
+		return "string"
+	case *structpb.Value_ListValue:
+		return "list"
+	case *structpb.Value_StructValue:
+		return "struct"
+	default:
+		return "null"
+	}
+}
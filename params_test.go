@@ -0,0 +1,206 @@
+package spantype
+
+import (
+	"errors"
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	. "github.com/apstndb/spantype/typector"
+)
+
+func mustParams(t *testing.T, m map[string]any) *structpb.Struct {
+	t.Helper()
+	s, err := structpb.NewStruct(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestFormatParams(t *testing.T) {
+	tests := []struct {
+		desc       string
+		params     map[string]any
+		paramTypes map[string]*sppb.Type
+		want       string
+	}{
+		{
+			desc:   "typed",
+			params: map[string]any{"id": "1", "tags": []any{"a"}},
+			paramTypes: map[string]*sppb.Type{
+				"id":   Int64(),
+				"tags": ElemCodeToArrayType(sppb.TypeCode_STRING),
+			},
+			want: `@id INT64 = 1, @tags ARRAY<STRING> = ["a"]`,
+		},
+		{
+			desc:   "STRUCT and NULL",
+			params: map[string]any{"s": []any{"1", nil}},
+			paramTypes: map[string]*sppb.Type{
+				"s": Struct(F("n", Int64()), F("t", String())),
+			},
+			want: `@s STRUCT<n INT64, t STRING> = (1, NULL)`,
+		},
+		{
+			desc:   "untyped",
+			params: map[string]any{"name": "a", "f": 1.5, "xs": []any{true, nil}, "n": nil},
+			want:   `@f /* FLOAT64 */ = 1.5, @n = NULL, @name /* STRING */ = "a", @xs /* ARRAY<BOOL> */ = [true, NULL]`,
+		},
+		{
+			desc:       "value is missing",
+			paramTypes: map[string]*sppb.Type{"id": Int64()},
+			want:       `@id INT64`,
+		},
+		{
+			desc: "empty",
+			want: ``,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := &sppb.ExecuteSqlRequest{Params: mustParams(t, tt.params), ParamTypes: tt.paramTypes}
+			if got := FormatExecuteSqlParams(req, FormatOptionVerbose); got != tt.want {
+				t.Errorf("FormatExecuteSqlParams want: %q, got: %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFormatParamsUnknownTypeCode(t *testing.T) {
+	opts := FormatOptionVerbose
+	opts.Unknown = UnknownModePanic
+	params := mustParams(t, map[string]any{"id": "1", "x": "a"})
+	paramTypes := map[string]*sppb.Type{"id": Int64(), "x": CodeToSimpleType(sppb.TypeCode(-1))}
+
+	want := `@id INT64 = 1, @x <unknown TypeCode(-1)> = "a"`
+	if got := FormatParams(params, paramTypes, opts); got != want {
+		t.Errorf("FormatParams want: %q, got: %q", want, got)
+	}
+
+	if got, err := TryFormatParams(params, paramTypes, opts); !errors.Is(err, ErrUnknownTypeCode) {
+		t.Errorf("TryFormatParams should fail with ErrUnknownTypeCode, but got: %q, %v", got, err)
+	}
+}
+
+func TestCheckParams(t *testing.T) {
+	tests := []struct {
+		desc       string
+		params     map[string]any
+		paramTypes map[string]*sppb.Type
+		want       []string
+	}{
+		{
+			desc:   "valid",
+			params: map[string]any{"id": "1", "f": 1.5, "inf": "Infinity", "b": true, "tags": []any{"a", nil}, "s": []any{"1", nil}, "null": nil},
+			paramTypes: map[string]*sppb.Type{
+				"id":   Int64(),
+				"f":    Float64(),
+				"inf":  Float32(),
+				"b":    Bool(),
+				"tags": ElemCodeToArrayType(sppb.TypeCode_STRING),
+				"s":    Struct(F("n", Int64()), F("t", String())),
+				"null": JSON(),
+			},
+		},
+		{
+			desc:   "param_type is missing",
+			params: map[string]any{"name": "a", "n": nil},
+			want: []string{
+				"@n: param_type is missing",
+				"@name: param_type is missing; inferred as STRING",
+			},
+		},
+		{
+			desc:       "value is missing",
+			paramTypes: map[string]*sppb.Type{"id": Int64()},
+			want:       []string{"@id: value is missing"},
+		},
+		{
+			desc:   "shape mismatch",
+			params: map[string]any{"id": 1.0, "list": []any{"a"}, "f": "1.5", "tags": "a", "b": "true"},
+			paramTypes: map[string]*sppb.Type{
+				"id":   Int64(),
+				"list": String(),
+				"f":    Float64(),
+				"tags": ElemCodeToArrayType(sppb.TypeCode_STRING),
+				"b":    Bool(),
+			},
+			want: []string{
+				"@b: string value for BOOL",
+				`@f: string value "1.5" for FLOAT64`,
+				"@id: number value for INT64",
+				"@list: list value for STRING",
+				"@tags: string value for ARRAY<STRING>",
+			},
+		},
+		{
+			desc:   "nested mismatch",
+			params: map[string]any{"tags": []any{"a", 1.0}, "s": []any{"1", []any{true}}, "short": []any{"1"}},
+			paramTypes: map[string]*sppb.Type{
+				"tags":  ElemCodeToArrayType(sppb.TypeCode_STRING),
+				"s":     Struct(F("n", Int64()), F("", ElemCodeToArrayType(sppb.TypeCode_INT64))),
+				"short": Struct(F("n", Int64()), F("t", String())),
+			},
+			want: []string{
+				"@s.#1[]: bool value for INT64",
+				"@short: 1 values for 2 fields of STRUCT<n INT64, t STRING>",
+				"@tags[]: number value for STRING",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := CheckExecuteSqlParams(&sppb.ExecuteSqlRequest{Params: mustParams(t, tt.params), ParamTypes: tt.paramTypes})
+			var got []string
+			if err != nil {
+				for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+					var perr *ParamError
+					if !errors.As(err, &perr) {
+						t.Errorf("error should be *ParamError, but %T", err)
+					}
+					got = append(got, err.Error())
+				}
+			}
+			if len(tt.want) != len(got) {
+				t.Fatalf("CheckExecuteSqlParams want: %q, got: %q", tt.want, got)
+			}
+			for i := range tt.want {
+				if tt.want[i] != got[i] {
+					t.Errorf("CheckExecuteSqlParams want: %q, got: %q", tt.want[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestInferParamType(t *testing.T) {
+	tests := []struct {
+		desc  string
+		value any
+		want  *sppb.Type
+	}{
+		{"bool", true, Bool()},
+		{"number", 1.0, Float64()},
+		{"string", "a", String()},
+		{"list", []any{nil, "a", "b"}, ElemCodeToArrayType(sppb.TypeCode_STRING)},
+		{"null", nil, nil},
+		{"empty list", []any{}, nil},
+		{"mixed list", []any{"a", 1.0}, nil},
+		{"nested list", []any{[]any{"a"}}, nil},
+		{"struct", map[string]any{"a": "b"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			value, err := structpb.NewValue(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := InferParamType(value); !proto.Equal(tt.want, got) {
+				t.Errorf("InferParamType want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
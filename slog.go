@@ -0,0 +1,192 @@
+package spantype
+
+import (
+	"context"
+	"log/slog"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+)
+
+// LogType returns a slog.LogValuer of the type.
+// It is logged as a string formatted using FormatOptionVerbose, or as configured by NewLogHandler.
+func LogType(typ *sppb.Type) slog.LogValuer {
+	return typeLogValuer{typ: typ}
+}
+
+// LogStructType returns a slog.LogValuer of the struct type. e.g. `metadata.rowType`
+// It is logged as a string formatted using FormatOptionVerbose, or as configured by NewLogHandler.
+func LogStructType(structType *sppb.StructType) slog.LogValuer {
+	return structTypeLogValuer{structType: structType}
+}
+
+// LogResultSetMetadata returns a slog.LogValuer of the row type and the undeclared parameters of the metadata.
+// It is logged as a group of strings formatted using FormatOptionVerbose, or as configured by NewLogHandler.
+func LogResultSetMetadata(metadata *sppb.ResultSetMetadata) slog.LogValuer {
+	return metadataLogValuer{metadata: metadata}
+}
+
+// LogHandlerOptions is an option for NewLogHandler.
+type LogHandlerOptions struct {
+	// Structured logs types as maps of `code`, `annotation`, `fqn`, `element`, and `fields`
+	// instead of formatted strings, so they are queryable in log backends.
+	Structured bool
+	// FormatOption is used for formatted strings. If it is nil, FormatOptionVerbose is used.
+	FormatOption *FormatOption
+}
+
+// NewLogHandler returns a slog.Handler which resolves values of LogType, LogStructType, and LogResultSetMetadata
+// using opts, and passes records to h. Other values are passed as is.
+func NewLogHandler(h slog.Handler, opts *LogHandlerOptions) slog.Handler {
+	if opts == nil {
+		opts = &LogHandlerOptions{}
+	}
+	return &logHandler{handler: h, opts: *opts}
+}
+
+// logValuer is implemented by the slog.LogValuer types of this package.
+type logValuer interface {
+	logValue(opts LogHandlerOptions) slog.Value
+}
+
+type logHandler struct {
+	handler slog.Handler
+	opts    LogHandlerOptions
+}
+
+func (h *logHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *logHandler) Handle(ctx context.Context, r slog.Record) error {
+	nr := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(attr slog.Attr) bool {
+		nr.AddAttrs(h.resolve(attr))
+		return true
+	})
+	return h.handler.Handle(ctx, nr)
+}
+
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	resolved := make([]slog.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		resolved = append(resolved, h.resolve(attr))
+	}
+	return &logHandler{handler: h.handler.WithAttrs(resolved), opts: h.opts}
+}
+
+func (h *logHandler) WithGroup(name string) slog.Handler {
+	return &logHandler{handler: h.handler.WithGroup(name), opts: h.opts}
+}
+
+func (h *logHandler) resolve(attr slog.Attr) slog.Attr {
+	switch attr.Value.Kind() {
+	case slog.KindLogValuer:
+		if v, ok := attr.Value.Any().(logValuer); ok {
+			return slog.Attr{Key: attr.Key, Value: v.logValue(h.opts)}
+		}
+	case slog.KindGroup:
+		group := attr.Value.Group()
+		resolved := make([]slog.Attr, 0, len(group))
+		for _, a := range group {
+			resolved = append(resolved, h.resolve(a))
+		}
+		return slog.Attr{Key: attr.Key, Value: slog.GroupValue(resolved...)}
+	}
+	return attr
+}
+
+func (o LogHandlerOptions) formatOption() FormatOption {
+	if o.FormatOption == nil {
+		return FormatOptionVerbose
+	}
+	return *o.FormatOption
+}
+
+type typeLogValuer struct {
+	typ *sppb.Type
+}
+
+func (v typeLogValuer) LogValue() slog.Value {
+	return v.logValue(LogHandlerOptions{})
+}
+
+func (v typeLogValuer) logValue(opts LogHandlerOptions) slog.Value {
+	if opts.Structured {
+		return slog.AnyValue(typeLogMap(v.typ))
+	}
+	return formattedLogValue(TryFormatType(v.typ, opts.formatOption()))
+}
+
+type structTypeLogValuer struct {
+	structType *sppb.StructType
+}
+
+func (v structTypeLogValuer) LogValue() slog.Value {
+	return v.logValue(LogHandlerOptions{})
+}
+
+func (v structTypeLogValuer) logValue(opts LogHandlerOptions) slog.Value {
+	if opts.Structured {
+		return slog.AnyValue(fieldsLogMaps(v.structType.GetFields()))
+	}
+	return formattedLogValue(TryFormatStructFields(v.structType.GetFields(), opts.formatOption()))
+}
+
+type metadataLogValuer struct {
+	metadata *sppb.ResultSetMetadata
+}
+
+func (v metadataLogValuer) LogValue() slog.Value {
+	return v.logValue(LogHandlerOptions{})
+}
+
+func (v metadataLogValuer) logValue(opts LogHandlerOptions) slog.Value {
+	attrs := []slog.Attr{
+		slog.Any("rowType", structTypeLogValuer{structType: v.metadata.GetRowType()}.logValue(opts)),
+	}
+	if params := v.metadata.GetUndeclaredParameters(); params != nil {
+		attrs = append(attrs, slog.Any("undeclaredParameters", structTypeLogValuer{structType: params}.logValue(opts)))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// formattedLogValue returns the formatted string, or the error string so that logging never panics,
+// e.g. on an unknown type code with UnknownModePanic.
+func formattedLogValue(s string, err error) slog.Value {
+	if err != nil {
+		return slog.StringValue(err.Error())
+	}
+	return slog.StringValue(s)
+}
+
+// fieldsLogMaps returns the structured representation of the fields.
+// slog has no array value, so fields are represented as a slice of maps.
+func fieldsLogMaps(fields []*sppb.StructType_Field) []map[string]any {
+	maps := make([]map[string]any, 0, len(fields))
+	for _, field := range fields {
+		maps = append(maps, map[string]any{
+			"name": field.GetName(),
+			"type": typeLogMap(field.GetType()),
+		})
+	}
+	return maps
+}
+
+// typeLogMap returns the structured representation of the type.
+// Maps are used at all levels so that top-level and nested types are logged in the same shape.
+func typeLogMap(typ *sppb.Type) map[string]any {
+	m := map[string]any{"code": FormatTypeCode(typ.GetCode(), UnknownModeVerbose)}
+	if annotation := typ.GetTypeAnnotation(); annotation != sppb.TypeAnnotationCode_TYPE_ANNOTATION_CODE_UNSPECIFIED {
+		m["annotation"] = annotation.String()
+	}
+	if fqn := typ.GetProtoTypeFqn(); fqn != "" {
+		m["fqn"] = fqn
+	}
+	if elem := typ.GetArrayElementType(); elem != nil {
+		m["element"] = typeLogMap(elem)
+	}
+	if structType := typ.GetStructType(); structType != nil {
+		m["fields"] = fieldsLogMaps(structType.GetFields())
+	}
+	return m
+}
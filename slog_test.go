@@ -0,0 +1,122 @@
+package spantype
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	. "github.com/apstndb/spantype/typector"
+)
+
+func TestLogValuer(t *testing.T) {
+	typ := NameTypeToStructType("arr", ElemTypeToArrayType(FQNToProtoType("examples.Book")))
+	metadata := &sppb.ResultSetMetadata{
+		RowType:              typ.GetStructType(),
+		UndeclaredParameters: &sppb.StructType{Fields: []*sppb.StructType_Field{NameCodeToStructTypeField("p", sppb.TypeCode_INT64)}},
+	}
+	normal := FormatOptionNormal
+
+	tests := []struct {
+		desc string
+		opts *LogHandlerOptions
+		want string
+	}{
+		{
+			desc: "without handler",
+			want: `{"type":"STRUCT<arr ARRAY<examples.Book>>","rowType":"arr ARRAY<examples.Book>","metadata":{"rowType":"arr ARRAY<examples.Book>","undeclaredParameters":"p INT64"}}`,
+		},
+		{
+			desc: "FormatOption",
+			opts: &LogHandlerOptions{FormatOption: &normal},
+			want: `{"type":"STRUCT<ARRAY<Book>>","rowType":"ARRAY<Book>","metadata":{"rowType":"ARRAY<Book>","undeclaredParameters":"INT64"}}`,
+		},
+		{
+			desc: "structured",
+			opts: &LogHandlerOptions{Structured: true},
+			want: `{
+  "type": {"code": "STRUCT", "fields": [{"name": "arr", "type": {"code": "ARRAY", "element": {"code": "PROTO", "fqn": "examples.Book"}}}]},
+  "rowType": [{"name": "arr", "type": {"code": "ARRAY", "element": {"code": "PROTO", "fqn": "examples.Book"}}}],
+  "metadata": {
+    "rowType": [{"name": "arr", "type": {"code": "ARRAY", "element": {"code": "PROTO", "fqn": "examples.Book"}}}],
+    "undeclaredParameters": [{"name": "p", "type": {"code": "INT64"}}]
+  }
+}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var buf bytes.Buffer
+			var h slog.Handler = slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey || a.Key == slog.MessageKey) {
+						return slog.Attr{}
+					}
+					return a
+				},
+			})
+			if tt.opts != nil {
+				h = NewLogHandler(h, tt.opts)
+			}
+
+			slog.New(h).With("type", LogType(typ)).Info("",
+				"rowType", LogStructType(metadata.GetRowType()),
+				"metadata", LogResultSetMetadata(metadata))
+
+			var want, got any
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(want, got) {
+				t.Errorf("log want: %v, got: %v", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestLogHandlerAnnotation(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewLogHandler(slog.NewJSONHandler(&buf, nil), &LogHandlerOptions{Structured: true})
+	slog.New(h).WithGroup("g").Info("msg", slog.Group("nested", "type", LogType(&sppb.Type{Code: sppb.TypeCode_NUMERIC, TypeAnnotation: sppb.TypeAnnotationCode_PG_NUMERIC})))
+
+	var got struct {
+		G struct {
+			Nested struct {
+				Type map[string]any `json:"type"`
+			} `json:"nested"`
+		} `json:"g"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"code": "NUMERIC", "annotation": "PG_NUMERIC"}
+	if !reflect.DeepEqual(want, got.G.Nested.Type) {
+		t.Errorf("log want: %v, got: %v", want, buf.String())
+	}
+}
+
+func TestLogHandlerUnknownModePanic(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewLogHandler(slog.NewJSONHandler(&buf, nil), &LogHandlerOptions{FormatOption: &FormatOption{Unknown: UnknownModePanic}})
+	slog.New(h).Info("msg",
+		"type", LogType(&sppb.Type{Code: -1}),
+		"rowType", LogStructType(&sppb.StructType{Fields: []*sppb.StructType_Field{{Name: "n", Type: &sppb.Type{Code: -1}}}}),
+	)
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"type", "rowType"} {
+		if s, _ := got[key].(string); !strings.Contains(s, ErrUnknownTypeCode.Error()) {
+			t.Errorf("log want: error string of %v, got: %v", key, buf.String())
+		}
+	}
+}
@@ -53,11 +53,21 @@ func CodeToSimpleType(code sppb.TypeCode) *sppb.Type {
 	return &sppb.Type{Code: code}
 }
 
+// CodeAnnotationToSimpleType returns a simple non-container type for the given code and type annotation.
+func CodeAnnotationToSimpleType(code sppb.TypeCode, annotation sppb.TypeAnnotationCode) *sppb.Type {
+	return &sppb.Type{Code: code, TypeAnnotation: annotation}
+}
+
 // ElemCodeToArrayType returns an ARRAY type with the given element type code.
 func ElemCodeToArrayType(code sppb.TypeCode) *sppb.Type {
 	return ElemTypeToArrayType(CodeToSimpleType(code))
 }
 
+// ElemCodeAnnotationToArrayType returns an ARRAY type with the given element type code and type annotation.
+func ElemCodeAnnotationToArrayType(code sppb.TypeCode, annotation sppb.TypeAnnotationCode) *sppb.Type {
+	return ElemTypeToArrayType(CodeAnnotationToSimpleType(code, annotation))
+}
+
 // ElemTypeToArrayType returns an ARRAY type with the given element type.
 func ElemTypeToArrayType(typ *sppb.Type) *sppb.Type {
 	return &sppb.Type{Code: sppb.TypeCode_ARRAY, ArrayElementType: typ}
@@ -95,6 +105,11 @@ func NameCodeToStructTypeField(name string, code sppb.TypeCode) *sppb.StructType
 	return NameTypeToStructTypeField(name, CodeToSimpleType(code))
 }
 
+// NameCodeAnnotationToStructTypeField returns a STRUCT field from a field name, type code, and type annotation.
+func NameCodeAnnotationToStructTypeField(name string, code sppb.TypeCode, annotation sppb.TypeAnnotationCode) *sppb.StructType_Field {
+	return NameTypeToStructTypeField(name, CodeAnnotationToSimpleType(code, annotation))
+}
+
 // NameTypeToStructTypeField returns a STRUCT field from a field name and type.
 func NameTypeToStructTypeField(name string, typ *sppb.Type) *sppb.StructType_Field {
 	return &sppb.StructType_Field{Name: name, Type: typ}
@@ -171,6 +186,49 @@ func MustNameCodeSlicesToStructTypeFields(names []string, codes []sppb.TypeCode)
 	return must(NameCodeSlicesToStructTypeFields(names, codes))
 }
 
+// Fluent constructors compose into a single expression for nested and wide types.
+// e.g. Struct(F("id", Int64()), F("tags", Array(String())), F("", Proto("examples.Book")))
+
+// Struct returns a STRUCT type with the given fields.
+func Struct(fields ...*sppb.StructType_Field) *sppb.Type {
+	return StructTypeFieldsToStructType(fields)
+}
+
+// F returns a STRUCT field with the given name and type. Use an empty name for an unnamed field.
+func F(name string, typ *sppb.Type) *sppb.StructType_Field {
+	return NameTypeToStructTypeField(name, typ)
+}
+
+// Array returns an ARRAY type with the given element type.
+func Array(elem *sppb.Type) *sppb.Type {
+	return ElemTypeToArrayType(elem)
+}
+
+// Proto returns a PROTO type for the given fully-qualified name.
+func Proto(fqn string) *sppb.Type {
+	return FQNToProtoType(fqn)
+}
+
+// Enum returns an ENUM type for the given fully-qualified name.
+func Enum(fqn string) *sppb.Type {
+	return FQNToEnumType(fqn)
+}
+
+// PGNumeric returns a NUMERIC type with PG_NUMERIC annotation, which is PostgreSQL numeric.
+func PGNumeric() *sppb.Type {
+	return CodeAnnotationToSimpleType(sppb.TypeCode_NUMERIC, sppb.TypeAnnotationCode_PG_NUMERIC)
+}
+
+// PGJSONB returns a JSON type with PG_JSONB annotation, which is PostgreSQL jsonb.
+func PGJSONB() *sppb.Type {
+	return CodeAnnotationToSimpleType(sppb.TypeCode_JSON, sppb.TypeAnnotationCode_PG_JSONB)
+}
+
+// PGOID returns an INT64 type with PG_OID annotation, which is PostgreSQL oid.
+func PGOID() *sppb.Type {
+	return CodeAnnotationToSimpleType(sppb.TypeCode_INT64, sppb.TypeAnnotationCode_PG_OID)
+}
+
 func must[T any](v T, err error) T {
 	if err != nil {
 		panic(err)
@@ -0,0 +1,74 @@
+package spantype
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+)
+
+// TypeFormatter wraps a type to implement fmt.Formatter. Use T to create it.
+//
+//   - `%v` formats the type using FormatOptionNormal.
+//   - `%+v` formats the type using FormatOptionVerbose.
+//   - `%#v` formats the type as a Go expression using typector. e.g. `typector.ElemTypeToArrayType(typector.Int64())`
+//   - `%s` formats the type using FormatOptionSimple.
+//   - `%q` is a double-quoted `%v`, and `%+q` is a double-quoted `%+v`.
+//
+// Width, precision, and `-` flag are handled in the same way as `%s`.
+type TypeFormatter struct {
+	typ *sppb.Type
+}
+
+// T returns a TypeFormatter of the type. e.g. `log.Printf("type: %+v", spantype.T(typ))`
+func T(typ *sppb.Type) TypeFormatter {
+	return TypeFormatter{typ: typ}
+}
+
+// String formats the type using FormatOptionNormal.
+func (t TypeFormatter) String() string {
+	return FormatTypeNormal(t.typ)
+}
+
+// Format implements fmt.Formatter.
+func (t TypeFormatter) Format(s fmt.State, verb rune) {
+	var str string
+	switch verb {
+	case 'v', 'q':
+		switch {
+		case verb == 'v' && s.Flag('#'):
+			str = string(appendGoSyntax(nil, t.typ, GoSyntaxOption{}.internal()))
+		case s.Flag('+'):
+			str = FormatTypeVerbose(t.typ)
+		default:
+			str = FormatTypeNormal(t.typ)
+		}
+		if verb == 'q' {
+			str = strconv.Quote(str)
+		}
+	case 's':
+		str = FormatTypeSimple(t.typ)
+	default:
+		fmt.Fprintf(s, "%%!%c(spantype.TypeFormatter=%v)", verb, FormatTypeNormal(t.typ))
+		return
+	}
+
+	if prec, ok := s.Precision(); ok && utf8.RuneCountInString(str) > prec {
+		runes := []rune(str)
+		str = string(runes[:prec])
+	}
+
+	var padding string
+	if width, ok := s.Width(); ok && width > utf8.RuneCountInString(str) {
+		padding = strings.Repeat(" ", width-utf8.RuneCountInString(str))
+	}
+	if s.Flag('-') {
+		str += padding
+	} else {
+		str = padding + str
+	}
+	io.WriteString(s, str)
+}
@@ -0,0 +1,45 @@
+package spantype
+
+import (
+	"fmt"
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	. "github.com/apstndb/spantype/typector"
+)
+
+func TestTypeFormatter(t *testing.T) {
+	typ := NameTypeToStructType("arr", ElemTypeToArrayType(FQNToProtoType("examples.Book")))
+
+	tests := []struct {
+		format string
+		typ    *sppb.Type
+		want   string
+	}{
+		{"%v", typ, "STRUCT<ARRAY<Book>>"},
+		{"%+v", typ, "STRUCT<arr ARRAY<examples.Book>>"},
+		{"%s", typ, "STRUCT"},
+		{"%q", typ, `"STRUCT<ARRAY<Book>>"`},
+		{"%+q", typ, `"STRUCT<arr ARRAY<examples.Book>>"`},
+		{"%#v", typ, `typector.NameTypeToStructType("arr", typector.ElemTypeToArrayType(typector.FQNToProtoType("examples.Book")))`},
+		{"[%8v]", Int64(), "[   INT64]"},
+		{"[%-8v]", Int64(), "[INT64   ]"},
+		{"[%.3v]", Int64(), "[INT]"},
+		{"%d", Int64(), "%!d(spantype.TypeFormatter=INT64)"},
+		{"%#v", MustNameTypeSlicesToStructType([]string{"a", ""}, []*sppb.Type{Int64(), FQNToEnumType("examples.Genre")}),
+			`typector.StructTypeFieldsToStructType([]*sppb.StructType_Field{typector.NameTypeToStructTypeField("a", typector.Int64()), typector.TypeToUnnamedStructTypeField(typector.FQNToEnumType("examples.Genre"))})`},
+		{"%#v", ElemTypeToArrayType(&sppb.Type{Code: sppb.TypeCode_NUMERIC, TypeAnnotation: sppb.TypeAnnotationCode_PG_NUMERIC}),
+			`typector.ElemTypeToArrayType(typector.PGNumeric())`},
+		{"%#v", CodeToSimpleType(-1), `typector.CodeToSimpleType(sppb.TypeCode(-1))`},
+		{"%#v", &sppb.Type{Code: sppb.TypeCode_ARRAY}, `&sppb.Type{Code: sppb.TypeCode_ARRAY}`},
+		{"%#v", nil, `nil`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if got := fmt.Sprintf(tt.format, T(tt.typ)); tt.want != got {
+				t.Errorf("Sprintf(%q) want: %v, got: %v", tt.format, tt.want, got)
+			}
+		})
+	}
+}
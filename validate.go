@@ -0,0 +1,151 @@
+package spantype
+
+import (
+	"errors"
+	"fmt"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ValidationError is a structural problem of a type reported by Validate.
+type ValidationError struct {
+	// Path is the location of the malformed type.
+	Path Path
+	// Message describes the problem.
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Path) == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("%v: %v", e.Path, e.Message)
+}
+
+// ValidateOption is an option for ValidateWithOption and ValidateStructFields.
+type ValidateOption struct {
+	// DisallowDuplicateFieldNames reports `STRUCT` fields with the same non-empty name.
+	// Query results can have duplicate names, but tables and named `STRUCT` parameters can't.
+	DisallowDuplicateFieldNames bool
+}
+
+// Validate reports all structural problems of the type, e.g. `ARRAY` without element type or `PROTO` without FQN.
+// The returned error joins *ValidationError values, and it is nil if the type is well-formed.
+func Validate(typ *sppb.Type) error {
+	return ValidateWithOption(typ, ValidateOption{})
+}
+
+// ValidateWithOption is like Validate, but it uses the given ValidateOption.
+func ValidateWithOption(typ *sppb.Type, opts ValidateOption) error {
+	var errs []error
+	Walk(typ, func(path Path, typ *sppb.Type) bool {
+		errs = append(errs, validateNode(path, typ, opts)...)
+		return true
+	})
+	return errors.Join(errs...)
+}
+
+// ValidateStructFields is like ValidateWithOption, but it validates struct fields or `metadata.rowType`.
+func ValidateStructFields(fields []*sppb.StructType_Field, opts ValidateOption) error {
+	var errs []error
+	if opts.DisallowDuplicateFieldNames {
+		errs = append(errs, validateFieldNames(nil, fields)...)
+	}
+	WalkStructFields(fields, func(path Path, typ *sppb.Type) bool {
+		errs = append(errs, validateNode(path, typ, opts)...)
+		return true
+	})
+	return errors.Join(errs...)
+}
+
+func validateNode(path Path, typ *sppb.Type, opts ValidateOption) []error {
+	if typ == nil {
+		return []error{&ValidationError{Path: path, Message: "type is missing"}}
+	}
+
+	var errs []error
+	report := func(format string, args ...any) {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	code := typ.GetCode()
+	codeStr := FormatTypeCode(code, UnknownModeVerbose)
+	switch code {
+	case sppb.TypeCode_TYPE_CODE_UNSPECIFIED:
+		report("type code is unspecified")
+	case sppb.TypeCode_ARRAY:
+		switch elem := typ.GetArrayElementType(); {
+		case elem == nil:
+			report("ARRAY without array_element_type")
+		case elem.GetCode() == sppb.TypeCode_ARRAY:
+			report("nested ARRAY is not allowed")
+		}
+	case sppb.TypeCode_STRUCT:
+		if typ.GetStructType() == nil {
+			report("STRUCT without struct_type")
+		}
+		if opts.DisallowDuplicateFieldNames {
+			errs = append(errs, validateFieldNames(path, typ.GetStructType().GetFields())...)
+		}
+	case sppb.TypeCode_PROTO, sppb.TypeCode_ENUM:
+		switch fqn := typ.GetProtoTypeFqn(); {
+		case fqn == "":
+			report("%v without proto_type_fqn", codeStr)
+		case !protoreflect.FullName(fqn).IsValid():
+			report("invalid proto_type_fqn %q", fqn)
+		}
+	default:
+		if _, ok := sppb.TypeCode_name[int32(code)]; !ok {
+			report("unknown type code %v", int32(code))
+		}
+	}
+
+	if code != sppb.TypeCode_ARRAY && typ.GetArrayElementType() != nil {
+		report("array_element_type is set on %v", codeStr)
+	}
+	if code != sppb.TypeCode_STRUCT && typ.GetStructType() != nil {
+		report("struct_type is set on %v", codeStr)
+	}
+	if code != sppb.TypeCode_PROTO && code != sppb.TypeCode_ENUM && typ.GetProtoTypeFqn() != "" {
+		report("proto_type_fqn is set on %v", codeStr)
+	}
+
+	switch annotation := typ.GetTypeAnnotation(); annotation {
+	case sppb.TypeAnnotationCode_TYPE_ANNOTATION_CODE_UNSPECIFIED:
+	case sppb.TypeAnnotationCode_PG_NUMERIC:
+		if code != sppb.TypeCode_NUMERIC {
+			report("%v is set on %v", annotation, codeStr)
+		}
+	case sppb.TypeAnnotationCode_PG_JSONB:
+		if code != sppb.TypeCode_JSON {
+			report("%v is set on %v", annotation, codeStr)
+		}
+	case sppb.TypeAnnotationCode_PG_OID:
+		if code != sppb.TypeCode_INT64 {
+			report("%v is set on %v", annotation, codeStr)
+		}
+	default:
+		report("unknown type annotation %v", int32(annotation))
+	}
+	return errs
+}
+
+func validateFieldNames(path Path, fields []*sppb.StructType_Field) []error {
+	var errs []error
+	seen := make(map[string]bool)
+	for i, field := range fields {
+		name := field.GetName()
+		if name == "" {
+			continue
+		}
+		if seen[name] {
+			errs = append(errs, &ValidationError{
+				Path:    path.Append(PathElem{Name: name, Index: i}),
+				Message: fmt.Sprintf("duplicate field name %q", name),
+			})
+		}
+		seen[name] = true
+	}
+	return errs
+}
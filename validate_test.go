@@ -0,0 +1,123 @@
+package spantype
+
+import (
+	"errors"
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	. "github.com/apstndb/spantype/typector"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		desc string
+		typ  *sppb.Type
+		opts ValidateOption
+		want []string
+	}{
+		{
+			desc: "valid",
+			typ:  NameTypeToStructType("arr", ElemTypeToArrayType(FQNToProtoType("examples.Outer.Inner"))),
+		},
+		{
+			desc: "ARRAY without element type",
+			typ:  &sppb.Type{Code: sppb.TypeCode_ARRAY},
+			want: []string{"ARRAY without array_element_type"},
+		},
+		{
+			desc: "nested ARRAY",
+			typ:  NameTypeToStructType("a", ElemTypeToArrayType(ElemCodeToArrayType(sppb.TypeCode_INT64))),
+			want: []string{"a: nested ARRAY is not allowed"},
+		},
+		{
+			desc: "STRUCT without struct_type",
+			typ:  ElemTypeToArrayType(&sppb.Type{Code: sppb.TypeCode_STRUCT}),
+			want: []string{"[]: STRUCT without struct_type"},
+		},
+		{
+			desc: "PROTO without FQN",
+			typ:  NameTypeToStructType("p", FQNToProtoType("")),
+			want: []string{"p: PROTO without proto_type_fqn"},
+		},
+		{
+			desc: "invalid FQN",
+			typ:  FQNToEnumType("examples..Enum"),
+			want: []string{`invalid proto_type_fqn "examples..Enum"`},
+		},
+		{
+			desc: "stray fields",
+			typ: &sppb.Type{
+				Code:             sppb.TypeCode_INT64,
+				ArrayElementType: Int64(),
+				StructType:       &sppb.StructType{},
+				ProtoTypeFqn:     "examples.Book",
+				TypeAnnotation:   sppb.TypeAnnotationCode_PG_JSONB,
+			},
+			want: []string{
+				"array_element_type is set on INT64",
+				"struct_type is set on INT64",
+				"proto_type_fqn is set on INT64",
+				"PG_JSONB is set on INT64",
+			},
+		},
+		{
+			desc: "missing and unknown types",
+			typ: StructTypeFieldsToStructType([]*sppb.StructType_Field{
+				{Name: "missing"},
+				NameCodeToStructTypeField("unspecified", sppb.TypeCode_TYPE_CODE_UNSPECIFIED),
+				NameCodeToStructTypeField("unknown", -1),
+			}),
+			want: []string{
+				"missing: type is missing",
+				"unspecified: type code is unspecified",
+				"unknown: unknown type code -1",
+			},
+		},
+		{
+			desc: "duplicate field names allowed",
+			typ:  MustNameCodeSlicesToStructType([]string{"n", "n", "", ""}, []sppb.TypeCode{sppb.TypeCode_INT64, sppb.TypeCode_INT64, sppb.TypeCode_INT64, sppb.TypeCode_INT64}),
+		},
+		{
+			desc: "duplicate field names disallowed",
+			typ:  NameTypeToStructType("s", MustNameCodeSlicesToStructType([]string{"n", "n", "", ""}, []sppb.TypeCode{sppb.TypeCode_INT64, sppb.TypeCode_INT64, sppb.TypeCode_INT64, sppb.TypeCode_INT64})),
+			opts: ValidateOption{DisallowDuplicateFieldNames: true},
+			want: []string{`s.n: duplicate field name "n"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := ValidateWithOption(tt.typ, tt.opts)
+			var got []string
+			if err != nil {
+				for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+					var verr *ValidationError
+					if !errors.As(err, &verr) {
+						t.Errorf("error should be *ValidationError, but %T", err)
+					}
+					got = append(got, err.Error())
+				}
+			}
+			if len(tt.want) != len(got) {
+				t.Fatalf("ValidateWithOption want: %q, got: %q", tt.want, got)
+			}
+			for i := range tt.want {
+				if tt.want[i] != got[i] {
+					t.Errorf("ValidateWithOption want: %q, got: %q", tt.want[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateStructFields(t *testing.T) {
+	fields := MustNameCodeSlicesToStructTypeFields([]string{"n", "n"}, []sppb.TypeCode{sppb.TypeCode_INT64, sppb.TypeCode_STRING})
+	if err := ValidateStructFields(fields, ValidateOption{}); err != nil {
+		t.Errorf("ValidateStructFields should succeed: %v", err)
+	}
+
+	want := `n: duplicate field name "n"`
+	if err := ValidateStructFields(fields, ValidateOption{DisallowDuplicateFieldNames: true}); err == nil || err.Error() != want {
+		t.Errorf("ValidateStructFields want: %v, got: %v", want, err)
+	}
+}
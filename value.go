@@ -0,0 +1,244 @@
+package spantype
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ProtoValueMode controls how PROTO values are rendered by FormatValue.
+type ProtoValueMode int
+
+const (
+	// ProtoValueModeRaw formats `PROTO` value as base64 encoded bytes as is.
+	ProtoValueModeRaw ProtoValueMode = iota
+	// ProtoValueModeText formats `PROTO` value as single-line protobuf text format. e.g. `{title: "Go"}`
+	ProtoValueModeText
+	// ProtoValueModeJSON formats `PROTO` value as protojson. e.g. `{"title":"Go"}`
+	ProtoValueModeJSON
+)
+
+// EnumValueMode controls how ENUM values are rendered by FormatValue.
+type EnumValueMode int
+
+const (
+	// EnumValueModeRaw formats `ENUM` value as number as is. e.g. `1`
+	EnumValueModeRaw EnumValueMode = iota
+	// EnumValueModeName formats `ENUM` value as symbolic name. e.g. `FICTION`
+	EnumValueModeName
+)
+
+// FormatValue formats a Cloud Spanner value of the given type using the given FormatOption.
+// `NULL` is formatted as `NULL`, `ARRAY` as `[elem, ...]`, `STRUCT` as `(field, ...)`,
+// and string-encoded values other than numbers are quoted.
+// `PROTO` and `ENUM` values are decoded with FormatOption.Resolver, and fall back to the raw form
+// when the descriptor is missing or the value can't be decoded.
+func FormatValue(typ *sppb.Type, value *structpb.Value, opts FormatOption) string {
+	if value == nil {
+		return "NULL"
+	}
+	if _, ok := value.GetKind().(*structpb.Value_NullValue); ok {
+		return "NULL"
+	}
+
+	switch typ.GetCode() {
+	case sppb.TypeCode_ARRAY:
+		if list, ok := value.GetKind().(*structpb.Value_ListValue); ok {
+			var elems []string
+			for _, v := range list.ListValue.GetValues() {
+				elems = append(elems, FormatValue(typ.GetArrayElementType(), v, opts))
+			}
+			return "[" + strings.Join(elems, ", ") + "]"
+		}
+	case sppb.TypeCode_STRUCT:
+		if list, ok := value.GetKind().(*structpb.Value_ListValue); ok {
+			fields := typ.GetStructType().GetFields()
+			var elems []string
+			for i, v := range list.ListValue.GetValues() {
+				var fieldType *sppb.Type
+				if i < len(fields) {
+					fieldType = fields[i].GetType()
+				}
+				elems = append(elems, FormatValue(fieldType, v, opts))
+			}
+			return "(" + strings.Join(elems, ", ") + ")"
+		}
+	case sppb.TypeCode_PROTO:
+		if s, ok := value.GetKind().(*structpb.Value_StringValue); ok {
+			if formatted, ok := formatProtoValue(typ.GetProtoTypeFqn(), s.StringValue, opts); ok {
+				return formatted
+			}
+		}
+	case sppb.TypeCode_ENUM:
+		if s, ok := value.GetKind().(*structpb.Value_StringValue); ok {
+			if formatted, ok := formatEnumValue(typ.GetProtoTypeFqn(), s.StringValue, opts); ok {
+				return formatted
+			}
+			return s.StringValue
+		}
+	case sppb.TypeCode_INT64, sppb.TypeCode_NUMERIC, sppb.TypeCode_FLOAT64, sppb.TypeCode_FLOAT32:
+		// Numbers are encoded as string, and non-finite floats are encoded as `NaN`, `Infinity`, or `-Infinity`.
+		if s, ok := value.GetKind().(*structpb.Value_StringValue); ok {
+			return s.StringValue
+		}
+		if n, ok := value.GetKind().(*structpb.Value_NumberValue); ok && typ.GetCode() == sppb.TypeCode_FLOAT32 {
+			return strconv.FormatFloat(n.NumberValue, 'g', -1, 32)
+		}
+	}
+
+	return formatValueGeneric(value)
+}
+
+// formatValueGeneric formats a value without type information.
+// It is used when the value doesn't match the type.
+func formatValueGeneric(value *structpb.Value) string {
+	switch kind := value.GetKind().(type) {
+	case *structpb.Value_BoolValue:
+		return strconv.FormatBool(kind.BoolValue)
+	case *structpb.Value_NumberValue:
+		return strconv.FormatFloat(kind.NumberValue, 'g', -1, 64)
+	case *structpb.Value_StringValue:
+		return strconv.Quote(kind.StringValue)
+	case *structpb.Value_ListValue:
+		var elems []string
+		for _, v := range kind.ListValue.GetValues() {
+			elems = append(elems, formatValueGeneric(v))
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	case *structpb.Value_StructValue:
+		b, err := protojson.Marshal(kind.StructValue)
+		if err != nil {
+			return "{}"
+		}
+		return string(b)
+	default:
+		return "NULL"
+	}
+}
+
+func formatProtoValue(fqn, encoded string, opts FormatOption) (string, bool) {
+	if opts.ProtoValue == ProtoValueModeRaw || opts.Resolver == nil {
+		return "", false
+	}
+
+	desc, err := opts.Resolver.FindDescriptorByName(protoreflect.FullName(fqn))
+	if err != nil {
+		return "", false
+	}
+	md, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return "", false
+	}
+
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", false
+	}
+
+	msg := dynamicpb.NewMessage(md)
+	if err := proto.Unmarshal(b, msg); err != nil {
+		return "", false
+	}
+
+	switch opts.ProtoValue {
+	case ProtoValueModeText:
+		b, err := prototext.MarshalOptions{}.Marshal(msg)
+		if err != nil {
+			return "", false
+		}
+		return "{" + normalizeText(string(b)) + "}", true
+	case ProtoValueModeJSON:
+		b, err := protojson.MarshalOptions{}.Marshal(msg)
+		if err != nil {
+			return "", false
+		}
+		// protojson randomizes whitespace, so the output is compacted.
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, b); err != nil {
+			return "", false
+		}
+		return buf.String(), true
+	default:
+		return "", false
+	}
+}
+
+// normalizeText normalizes whitespace of single-line protobuf text format, which prototext randomizes.
+// Field names are followed by `: `, and fields and list elements are separated by a single space and `, `. e.g. `title: "Go" inner: {n: 1}`
+func normalizeText(s string) string {
+	var sb strings.Builder
+	var quote byte
+	var escaped, space bool
+	var prev byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if quote != 0 {
+			sb.WriteByte(c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == quote:
+				quote = 0
+				prev = c
+			}
+			continue
+		}
+
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			space = true
+			continue
+		}
+		switch {
+		case prev == ':' || prev == ',':
+			sb.WriteByte(' ')
+		case space && prev != 0 && !strings.ContainsRune("{[<", rune(prev)) && !strings.ContainsRune("}]>,:", rune(c)):
+			sb.WriteByte(' ')
+		}
+		space = false
+		sb.WriteByte(c)
+		if c == '"' || c == '\'' {
+			quote = c
+		}
+		prev = c
+	}
+	return sb.String()
+}
+
+func formatEnumValue(fqn, number string, opts FormatOption) (string, bool) {
+	if opts.EnumValue != EnumValueModeName || opts.Resolver == nil {
+		return "", false
+	}
+
+	desc, err := opts.Resolver.FindDescriptorByName(protoreflect.FullName(fqn))
+	if err != nil {
+		return "", false
+	}
+	ed, ok := desc.(protoreflect.EnumDescriptor)
+	if !ok {
+		return "", false
+	}
+
+	n, err := strconv.ParseInt(number, 10, 32)
+	if err != nil {
+		return "", false
+	}
+
+	v := ed.Values().ByNumber(protoreflect.EnumNumber(n))
+	if v == nil {
+		return "", false
+	}
+	return string(v.Name()), true
+}
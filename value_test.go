@@ -0,0 +1,172 @@
+package spantype
+
+import (
+	"encoding/base64"
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	. "github.com/apstndb/spantype/typector"
+)
+
+func TestFormatValue(t *testing.T) {
+	files := testFiles(t)
+
+	desc, err := files.FindDescriptorByName("examples.shop.Book")
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := desc.(protoreflect.MessageDescriptor)
+	book := dynamicpb.NewMessage(md)
+	book.Set(md.Fields().ByName("title"), protoreflect.ValueOfString("Go"))
+	book.Set(md.Fields().ByName("genre"), protoreflect.ValueOfEnum(1))
+	b, err := proto.Marshal(book)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encodedBook := base64.StdEncoding.EncodeToString(b)
+
+	withResolver := func(opts FormatOption) FormatOption {
+		opts.Resolver = files
+		return opts
+	}
+
+	tests := []struct {
+		desc  string
+		typ   *sppb.Type
+		value *structpb.Value
+		opts  FormatOption
+		want  string
+	}{
+		{
+			desc:  "NULL",
+			typ:   Int64(),
+			value: structpb.NewNullValue(),
+			opts:  FormatOptionNormal,
+			want:  "NULL",
+		},
+		{
+			desc:  "INT64",
+			typ:   Int64(),
+			value: structpb.NewStringValue("1"),
+			opts:  FormatOptionNormal,
+			want:  "1",
+		},
+		{
+			desc:  "STRING",
+			typ:   String(),
+			value: structpb.NewStringValue(`a"b`),
+			opts:  FormatOptionNormal,
+			want:  `"a\"b"`,
+		},
+		{
+			desc:  "FLOAT64 NaN",
+			typ:   Float64(),
+			value: structpb.NewStringValue("NaN"),
+			opts:  FormatOptionNormal,
+			want:  "NaN",
+		},
+		{
+			desc:  "ARRAY<STRUCT>",
+			typ:   ElemTypeToArrayType(MustNameTypeSlicesToStructType([]string{"n", "s"}, []*sppb.Type{Int64(), String()})),
+			value: structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{structpb.NewStringValue("1"), structpb.NewNullValue()}})}}),
+			opts:  FormatOptionNormal,
+			want:  "[(1, NULL)]",
+		},
+		{
+			desc:  "mismatched value",
+			typ:   Int64(),
+			value: structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{structpb.NewBoolValue(true)}}),
+			opts:  FormatOptionNormal,
+			want:  "[true]",
+		},
+		{
+			desc:  "ENUM name",
+			typ:   FQNToEnumType("examples.shop.Genre"),
+			value: structpb.NewStringValue("1"),
+			opts:  withResolver(FormatOptionNormal),
+			want:  "FICTION",
+		},
+		{
+			desc:  "ENUM raw",
+			typ:   FQNToEnumType("examples.shop.Genre"),
+			value: structpb.NewStringValue("1"),
+			opts:  withResolver(FormatOptionSimple),
+			want:  "1",
+		},
+		{
+			desc:  "ENUM undefined number",
+			typ:   FQNToEnumType("examples.shop.Genre"),
+			value: structpb.NewStringValue("100"),
+			opts:  withResolver(FormatOptionNormal),
+			want:  "100",
+		},
+		{
+			desc:  "ENUM without resolver",
+			typ:   FQNToEnumType("examples.shop.Genre"),
+			value: structpb.NewStringValue("1"),
+			opts:  FormatOptionNormal,
+			want:  "1",
+		},
+		{
+			desc:  "PROTO text",
+			typ:   FQNToProtoType("examples.shop.Book"),
+			value: structpb.NewStringValue(encodedBook),
+			opts:  withResolver(FormatOptionNormal),
+			want:  `{title: "Go" genre: FICTION}`,
+		},
+		{
+			desc:  "PROTO JSON",
+			typ:   FQNToProtoType("examples.shop.Book"),
+			value: structpb.NewStringValue(encodedBook),
+			opts:  withResolver(FormatOption{ProtoValue: ProtoValueModeJSON}),
+			want:  `{"title":"Go","genre":"FICTION"}`,
+		},
+		{
+			desc:  "PROTO raw",
+			typ:   FQNToProtoType("examples.shop.Book"),
+			value: structpb.NewStringValue(encodedBook),
+			opts:  withResolver(FormatOptionSimple),
+			want:  `"` + encodedBook + `"`,
+		},
+		{
+			desc:  "PROTO without descriptor",
+			typ:   FQNToProtoType("examples.shop.Unknown"),
+			value: structpb.NewStringValue(encodedBook),
+			opts:  withResolver(FormatOptionNormal),
+			want:  `"` + encodedBook + `"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := FormatValue(tt.typ, tt.value, tt.opts); tt.want != got {
+				t.Errorf("FormatValue want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		desc  string
+		input string
+		want  string
+	}{
+		{"compact", `title:"Go" genre:FICTION`, `title: "Go" genre: FICTION`},
+		{"extra spaces", "title:  \"Go\"  genre:   FICTION ", `title: "Go" genre: FICTION`},
+		{"nested message", `inner:{n:1}  inner: { n: 2 }`, `inner: {n: 1} inner: {n: 2}`},
+		{"list", `ns:[1,2 , 3]`, `ns: [1, 2, 3]`},
+		{"quoted spaces and escapes", `s:  "a:  \" {b}"  t:'c  d'`, `s: "a:  \" {b}" t: 'c  d'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := normalizeText(tt.input); tt.want != got {
+				t.Errorf("normalizeText want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package spantype
+
+import (
+	"strconv"
+	"strings"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+)
+
+// PathElem is an element of Path.
+type PathElem struct {
+	// Elem is true if the element is the element type of `ARRAY`.
+	Elem bool
+	// Name is the name of the `STRUCT` field. It may be empty for unnamed fields.
+	Name string
+	// Index is the index of the `STRUCT` field.
+	Index int
+}
+
+// String formats the element as `name` for named fields, `#index` for unnamed fields, and `[]` for array elements.
+func (e PathElem) String() string {
+	switch {
+	case e.Elem:
+		return "[]"
+	case e.Name != "":
+		return e.Name
+	default:
+		return "#" + strconv.Itoa(e.Index)
+	}
+}
+
+// Path is a location of a type in a type tree.
+type Path []PathElem
+
+// String formats the path. e.g. `tags[]`, `s.n`, `outer[].#0`
+func (p Path) String() string {
+	var sb strings.Builder
+	for i, e := range p {
+		if i > 0 && !e.Elem {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(e.String())
+	}
+	return sb.String()
+}
+
+// Append returns a new Path with the element appended. It always allocates, so the paths of siblings
+// don't share the backing array, and callers such as WalkFunc can retain them.
+func (p Path) Append(elem PathElem) Path {
+	return append(p[:len(p):len(p)], elem)
+}
+
+// WalkFunc is the type of the function called by Walk and WalkStructFields for each type.
+// If it returns false, the children of the type are skipped.
+type WalkFunc func(path Path, typ *sppb.Type) bool
+
+// Walk calls fn for typ and all of its descendants in depth-first order.
+// The path of typ itself is empty.
+func Walk(typ *sppb.Type, fn WalkFunc) {
+	walk(nil, typ, fn)
+}
+
+// WalkStructFields is like Walk, but it walks the types of all fields, e.g. `metadata.rowType.fields`.
+// Paths start with the field.
+func WalkStructFields(fields []*sppb.StructType_Field, fn WalkFunc) {
+	walkStructFields(nil, fields, fn)
+}
+
+func walk(path Path, typ *sppb.Type, fn WalkFunc) {
+	if !fn(path, typ) {
+		return
+	}
+
+	switch typ.GetCode() {
+	case sppb.TypeCode_ARRAY:
+		if typ.GetArrayElementType() != nil {
+			walk(path.Append(PathElem{Elem: true}), typ.GetArrayElementType(), fn)
+		}
+	case sppb.TypeCode_STRUCT:
+		walkStructFields(path, typ.GetStructType().GetFields(), fn)
+	}
+}
+
+func walkStructFields(path Path, fields []*sppb.StructType_Field, fn WalkFunc) {
+	for i, field := range fields {
+		walk(path.Append(PathElem{Name: field.GetName(), Index: i}), field.GetType(), fn)
+	}
+}
@@ -0,0 +1,19 @@
+package spantype
+
+import "testing"
+
+func TestPathAppend(t *testing.T) {
+	base := make(Path, 1, 4)
+	base[0] = PathElem{Name: "s"}
+	a := base.Append(PathElem{Elem: true})
+	b := base.Append(PathElem{Name: "n", Index: 1})
+	if want, got := "s[]", a.String(); want != got {
+		t.Errorf("Append want: %v, got: %v", want, got)
+	}
+	if want, got := "s.n", b.String(); want != got {
+		t.Errorf("Append want: %v, got: %v", want, got)
+	}
+	if want, got := "s", base.String(); want != got {
+		t.Errorf("Append must not modify the receiver, want: %v, got: %v", want, got)
+	}
+}